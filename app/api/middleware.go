@@ -1,6 +1,8 @@
 package api
 
 import (
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/pkg/exception"
 	"boiler-plate/pkg/getfilter"
 	"net/http"
 
@@ -12,15 +14,15 @@ func ResponseHeaderFormat() gin.HandlerFunc {
 	}
 }
 
-func FilterMiddle() gin.HandlerFunc {
+// FilterMiddle parses the filter and sort query of list routes and rejects
+// unknown filter operators.
+func FilterMiddle(base *handler.BaseHTTPHandler) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		err := getfilter.Handle(c)
-		if err {
-			c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
-				"status":  http.StatusNotAcceptable,
-				"message": "query invalid",
-			})
+		if getfilter.Handle(c) {
+			exc := exception.InvalidArgument("query invalid")
+			exc.Status = http.StatusNotAcceptable
+			base.AbortWithException(c, exc)
+			return
 		}
 
 		c.Next()
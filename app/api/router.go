@@ -2,28 +2,111 @@ package api
 
 import (
 	"fmt"
+	"net/http"
 
+	"boiler-plate/internal/base/app"
 	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/settings/domain"
+	"boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/policy"
+	"boiler-plate/pkg/server"
+)
+
+var (
+	admin = policy.Roles(jwt.UserRoleAdmin)
+	staff = policy.Roles(jwt.UserRoleAdmin, jwt.UserRoleSales)
 )
 
 func (h *HttpServe) setupSettingsRouter() {
-	h.GuestRoute("GET", "/settings", h.settingsHandler.FindSettings)
+	findSettings := h.settingsHandler.FindSettings
+	// signed for the partners that need it once a key is configured, the
+	// public route keeps working without one
+	if h.base.ResponseSigner != nil {
+		findSettings = h.base.SignResponse(findSettings)
+	}
+	h.GuestRoute("GET", "/settings", findSettings)
+	h.UserRoute("PUT", "/settings", h.settingsHandler.UpdateSettings, admin)
+	h.UserRoute("PATCH", "/settings", h.settingsHandler.PatchSettings, admin)
+	for _, kind := range []string{domain.ImageLogo, domain.ImageFavicon} {
+		h.GuestRoute("GET", "/settings/"+kind, h.settingsHandler.ServeImage(kind))
+		h.UserRoute("POST", "/settings/"+kind, h.settingsHandler.UploadImage(kind), admin)
+		h.UserRoute("PUT", "/settings/"+kind, h.settingsHandler.UploadImageBase64(kind), admin)
+	}
+	h.UserRoute("GET", "/settings/revisions", h.settingsHandler.FindRevisions, admin)
+	h.UserRoute("GET", "/settings/revisions/diff", h.settingsHandler.DiffRevisions, admin)
+	h.UserRoute("GET", "/settings/revisions/:revision", h.settingsHandler.FindRevision, admin)
+	h.UserRoute("POST", "/settings/revisions/:revision/rollback", h.settingsHandler.RollbackSettings, admin)
+}
+
+func (h *HttpServe) setupAccountRouter() {
+	h.GuestRoute("GET", "/captcha", h.accountHandler.Captcha)
+	h.GuestRoute("POST", "/login", h.accountHandler.Login)
+	h.UserRoute("GET", "/account", h.accountHandler.FindProfile)
+	h.UserRoute("PUT", "/account/password", h.accountHandler.ChangePassword)
+	h.GuestRoute("PUT", "/account/password/expired", h.accountHandler.ChangeExpiredPassword)
 }
 
-func (h *HttpServe) UserRoute(method, path string, f handler.HandlerFnInterface) {
+func (h *HttpServe) setupAuthRouter() {
+	h.GuestRoute("POST", "/token/refresh", h.authHandler.Refresh)
+	h.UserRoute("POST", "/logout", h.authHandler.Logout)
+	h.UserRoute("POST", "/admin/accounts/:id/revoke-tokens", h.authHandler.RevokeAccount, admin)
+	h.router.GET("/.well-known/jwks.json", h.base.GuestRunAction(h.authHandler.JWKS))
+	h.GuestRoute("GET", "/response-signing-key", h.authHandler.ResponseSigningKey)
+	h.UserRoute("GET", "/admin/routes", h.listRoutes, admin)
+}
+
+func (h *HttpServe) setupClientRouter() {
+	h.GuestRoute("POST", "/oauth/token", h.clientHandler.Token)
+	h.ClientRoute("GET", "/client/me", h.clientHandler.Me)
+	h.SignedRoute("GET", "/b2b/me", h.clientHandler.Me)
+	h.UserRoute("POST", "/admin/clients", h.clientHandler.Create, admin)
+	h.UserRoute("GET", "/admin/clients", h.clientHandler.Find, admin)
+	h.UserRoute("DELETE", "/admin/clients/:client_id", h.clientHandler.Revoke, admin)
+	h.UserRoute("PUT", "/admin/clients/:client_id/public-key", h.clientHandler.SetPublicKey, admin)
+}
+
+func (h *HttpServe) setupRegistrationRouter() {
+	h.GuestRoute("POST", "/register", h.registrationHandler.Register)
+}
+
+func (h *HttpServe) setupVerifyRouter() {
+	h.GuestRoute("POST", "/verify", h.verificationHandler.Verify)
+}
+
+func (h *HttpServe) setupOTPRouter() {
+	h.GuestRoute("POST", "/otp/request", h.otpHandler.Request)
+	h.GuestRoute("POST", "/otp/verify", h.otpHandler.Verify)
+}
+
+func (h *HttpServe) setupInvestorCategoryRouter() {
+	h.UserRoute("POST", "/investor/category", h.investorCategoryHandler.Create, staff)
+	h.UserRoute("GET", "/investor/category", h.investorCategoryHandler.Find, staff)
+	h.UserRoute("GET", "/investor/category/select", h.investorCategoryHandler.FindSelect, staff)
+	h.UserRoute("GET", "/investor/category/:id", h.investorCategoryHandler.FindByID, staff)
+	h.UserRoute("DELETE", "/investor/category/:id", h.investorCategoryHandler.Delete, admin)
+}
+
+// UserRoute registers a route for authenticated callers. The token must pass
+// every one of policies, e.g. policy.Roles(jwt.UserRoleAdmin).
+func (h *HttpServe) UserRoute(method, path string, f handler.HandlerFnInterface, policies ...policy.Policy) {
 	userRoute := h.router.Group("/api/v2")
 	switch method {
 	case "GET":
-		userRoute.GET(path, h.base.UserRunAction(f))
+		userRoute.GET(path, h.base.UserRunAction(f, policies...))
 	case "POST":
-		userRoute.POST(path, h.base.UserRunAction(f))
+		userRoute.POST(path, h.base.UserRunAction(f, policies...))
 	case "PUT":
-		userRoute.PUT(path, h.base.UserRunAction(f))
+		userRoute.PUT(path, h.base.UserRunAction(f, policies...))
+	case "PATCH":
+		userRoute.PATCH(path, h.base.UserRunAction(f, policies...))
 	case "DELETE":
-		userRoute.DELETE(path, h.base.UserRunAction(f))
+		userRoute.DELETE(path, h.base.UserRunAction(f, policies...))
 	default:
 		panic(fmt.Sprintf(":%s method not allow", method))
 	}
+	h.routes = append(h.routes, routePolicy{
+		Method: method, Path: userRoute.BasePath() + path, Auth: "user", Policies: policies,
+	})
 }
 
 func (h *HttpServe) GuestRoute(method, path string, f handler.HandlerFnInterface) {
@@ -35,9 +118,74 @@ func (h *HttpServe) GuestRoute(method, path string, f handler.HandlerFnInterface
 		guestRoute.POST(path, h.base.GuestRunAction(f))
 	case "PUT":
 		guestRoute.PUT(path, h.base.GuestRunAction(f))
+	case "PATCH":
+		guestRoute.PATCH(path, h.base.GuestRunAction(f))
 	case "DELETE":
 		guestRoute.DELETE(path, h.base.GuestRunAction(f))
 	default:
 		panic(fmt.Sprintf(":%s method not allow", method))
 	}
+	h.routes = append(h.routes, routePolicy{Method: method, Path: guestRoute.BasePath() + path, Auth: "guest"})
+}
+
+// ClientRoute registers a route for machine clients holding a
+// client_credentials token with every one of scopes.
+func (h *HttpServe) ClientRoute(method, path string, f handler.HandlerFnInterface, scopes ...string) {
+	clientRoute := h.router.Group("/api/v2")
+	switch method {
+	case "GET":
+		clientRoute.GET(path, h.base.ClientRunAction(f, scopes...))
+	case "POST":
+		clientRoute.POST(path, h.base.ClientRunAction(f, scopes...))
+	case "PUT":
+		clientRoute.PUT(path, h.base.ClientRunAction(f, scopes...))
+	case "PATCH":
+		clientRoute.PATCH(path, h.base.ClientRunAction(f, scopes...))
+	case "DELETE":
+		clientRoute.DELETE(path, h.base.ClientRunAction(f, scopes...))
+	default:
+		panic(fmt.Sprintf(":%s method not allow", method))
+	}
+	h.routes = append(h.routes, routePolicy{
+		Method: method, Path: clientRoute.BasePath() + path, Auth: "client", Scopes: scopes,
+	})
+}
+
+// SignedRoute registers a B2B route authenticated by a request signature
+// made with the private key of a registered client, see
+// BaseHTTPHandler.SignedRunAction.
+func (h *HttpServe) SignedRoute(method, path string, f handler.HandlerFnInterface, scopes ...string) {
+	signedRoute := h.router.Group("/api/v2")
+	switch method {
+	case "GET":
+		signedRoute.GET(path, h.base.SignedRunAction(f, scopes...))
+	case "POST":
+		signedRoute.POST(path, h.base.SignedRunAction(f, scopes...))
+	case "PUT":
+		signedRoute.PUT(path, h.base.SignedRunAction(f, scopes...))
+	case "PATCH":
+		signedRoute.PATCH(path, h.base.SignedRunAction(f, scopes...))
+	case "DELETE":
+		signedRoute.DELETE(path, h.base.SignedRunAction(f, scopes...))
+	default:
+		panic(fmt.Sprintf(":%s method not allow", method))
+	}
+	h.routes = append(h.routes, routePolicy{
+		Method: method, Path: signedRoute.BasePath() + path, Auth: "signature", Scopes: scopes,
+	})
+}
+
+// routePolicy describes what guards a route, listed by /admin/routes.
+type routePolicy struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Auth     string          `json:"auth"`
+	Policies []policy.Policy `json:"policies"`
+	Scopes   []string        `json:"scopes,omitempty"`
+}
+
+// listRoutes returns every route registered through UserRoute, GuestRoute,
+// ClientRoute and SignedRoute with the policies or scopes guarding it.
+func (h *HttpServe) listRoutes(ctx *app.Context) *server.ResponseInterface {
+	return h.base.AsData(ctx, http.StatusOK, handler.MessageSuccess, h.routes)
 }
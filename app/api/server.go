@@ -6,8 +6,15 @@ import (
 	"os"
 	"strings"
 
+	accountHandler "boiler-plate/internal/account/handler"
+	authHandler "boiler-plate/internal/auth/handler"
 	"boiler-plate/internal/base/handler"
+	clientHandler "boiler-plate/internal/client/handler"
+	investorCategoryHandler "boiler-plate/internal/investorcategory/handler"
+	otpHandler "boiler-plate/internal/otp/handler"
+	registrationHandler "boiler-plate/internal/registration/handler"
 	tempHandler "boiler-plate/internal/settings/handler"
+	verificationHandler "boiler-plate/internal/verification/handler"
 	"boiler-plate/pkg/server"
 
 	"github.com/gin-contrib/cors"
@@ -16,16 +23,27 @@ import (
 )
 
 type HttpServe struct {
-	router          *gin.Engine
-	base            *handler.BaseHTTPHandler
-	settingsHandler *tempHandler.HTTPHandler
+	router                  *gin.Engine
+	base                    *handler.BaseHTTPHandler
+	settingsHandler         *tempHandler.HTTPHandler
+	accountHandler          *accountHandler.HTTPHandler
+	authHandler             *authHandler.HTTPHandler
+	clientHandler           *clientHandler.HTTPHandler
+	registrationHandler     *registrationHandler.HTTPHandler
+	verificationHandler     *verificationHandler.HTTPHandler
+	otpHandler              *otpHandler.HTTPHandler
+	investorCategoryHandler *investorCategoryHandler.HTTPHandler
+	routes                  []routePolicy
 }
 
 func (h *HttpServe) Run(config *appconf.Config) error {
 	h.setupSettingsRouter()
 	h.setupAccountRouter()
+	h.setupAuthRouter()
+	h.setupClientRouter()
 	h.setupRegistrationRouter()
 	h.setupVerifyRouter()
+	h.setupOTPRouter()
 	h.setupInvestorCategoryRouter()
 	h.setupDevRouter(config)
 	h.base.Handlers = h
@@ -40,6 +58,13 @@ func (h *HttpServe) Run(config *appconf.Config) error {
 func New(
 	appName string, base *handler.BaseHTTPHandler,
 	settings *tempHandler.HTTPHandler,
+	account *accountHandler.HTTPHandler,
+	auth *authHandler.HTTPHandler,
+	client *clientHandler.HTTPHandler,
+	registration *registrationHandler.HTTPHandler,
+	verification *verificationHandler.HTTPHandler,
+	otp *otpHandler.HTTPHandler,
+	investorCategory *investorCategoryHandler.HTTPHandler,
 ) server.App {
 
 	if os.Getenv("APP_ENV") != "production" {
@@ -71,8 +96,15 @@ func New(
 	}))
 
 	return &HttpServe{
-		router:          r,
-		base:            base,
-		settingsHandler: settings,
+		router:                  r,
+		base:                    base,
+		settingsHandler:         settings,
+		accountHandler:          account,
+		authHandler:             auth,
+		clientHandler:           client,
+		registrationHandler:     registration,
+		verificationHandler:     verification,
+		otpHandler:              otp,
+		investorCategoryHandler: investorCategory,
 	}
 }
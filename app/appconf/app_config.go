@@ -13,11 +13,15 @@ type AppConfig struct {
 	AppVersion   string   `validate:"required,startswith=v,alphanum" name:"APP_VERSION"`
 	AppName      string   `validate:"required" name:"APP_NAME"`
 	HttpPort     string   `validate:"required,number" name:"HTTP_PORT"`
+	GrpcPort     string   `validate:"required,number" name:"GRPC_PORT"`
 	AllowOrigins []string `validate:"required" name:"ALLOW_ORIGINS"`
 	AllowMethods []string `validate:"required" name:"ALLOW_METHODS"`
 	AllowHeaders []string `validate:"required" name:"ALLOW_HEADERS"`
 	FilePath     string   `validate:"required" name:"FILE_PATH"`
 	FileMaxSize  int      `validate:"required,number" name:"FILE_MAX_SIZE"`
+	// ResponseFormat is envelope or legacy, the latter keeps the
+	// responseCode/responseMessage body for clients not migrated yet.
+	ResponseFormat string `validate:"required,eq=envelope|eq=legacy" name:"RESPONSE_FORMAT"`
 }
 
 func AppConfigInit() *AppConfig {
@@ -26,16 +30,26 @@ func AppConfigInit() *AppConfig {
 	if err != nil {
 		logrus.Panicf("FILE_MAX_SIZE must be int")
 	}
+	responseFormat := os.Getenv("RESPONSE_FORMAT")
+	if responseFormat == "" {
+		responseFormat = "envelope"
+	}
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9005"
+	}
 	return &AppConfig{
-		AppEnv:       os.Getenv("APP_ENV"),
-		AppDebug:     os.Getenv("APP_DEBUG"),
-		AppVersion:   os.Getenv("APP_VERSION"),
-		AppName:      os.Getenv("APP_NAME"),
-		HttpPort:     os.Getenv("HTTP_PORT"),
-		AllowOrigins: strings.Split(os.Getenv("ALLOW_ORIGINS"), ","),
-		AllowMethods: strings.Split(os.Getenv("ALLOW_METHODS"), ","),
-		AllowHeaders: strings.Split(os.Getenv("ALLOW_HEADERS"), ","),
-		FilePath:     os.Getenv("FILE_PATH"),
-		FileMaxSize:  maxSizeInt,
+		AppEnv:         os.Getenv("APP_ENV"),
+		AppDebug:       os.Getenv("APP_DEBUG"),
+		AppVersion:     os.Getenv("APP_VERSION"),
+		AppName:        os.Getenv("APP_NAME"),
+		HttpPort:       os.Getenv("HTTP_PORT"),
+		GrpcPort:       grpcPort,
+		AllowOrigins:   strings.Split(os.Getenv("ALLOW_ORIGINS"), ","),
+		AllowMethods:   strings.Split(os.Getenv("ALLOW_METHODS"), ","),
+		AllowHeaders:   strings.Split(os.Getenv("ALLOW_HEADERS"), ","),
+		FilePath:       os.Getenv("FILE_PATH"),
+		FileMaxSize:    maxSizeInt,
+		ResponseFormat: responseFormat,
 	}
 }
@@ -1,13 +1,71 @@
 package appconf
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 type AuthConfig struct {
-	JwtSecretAccessToken []byte `validate:"required" name:"JWT_SECRET_ACCESS_TOKEN"`
+	// JwtSecretAccessToken is the HS256 secret. With JwtKeysFile set it only
+	// verifies tokens issued before the switch to the key file.
+	JwtSecretAccessToken []byte `validate:"required_without=JwtKeysFile" name:"JWT_SECRET_ACCESS_TOKEN"`
+	// JwtLegacyNotAfter is when the key file took over signing from the
+	// HS256 secret. The secret verifies older tokens for one access token TTL
+	// after it, then it is ignored and can be removed.
+	JwtLegacyNotAfter time.Time `name:"JWT_LEGACY_NOT_AFTER"`
+	// JwtKeysFile lists the signing keys and their rotation schedule, see
+	// jwt.KeyFile.
+	JwtKeysFile        string        `validate:"omitempty,file" name:"JWT_KEYS_FILE"`
+	JwtAccessTokenTTL  time.Duration `validate:"required" name:"JWT_ACCESS_TOKEN_TTL"`
+	JwtRefreshTokenTTL time.Duration `validate:"required" name:"JWT_REFRESH_TOKEN_TTL"`
+	// JwtSessionTTL is the absolute lifetime of a login, refreshing never
+	// extends it.
+	JwtSessionTTL time.Duration `validate:"required" name:"JWT_SESSION_TTL"`
+	// JwtRevocationStore keeps revoked tokens, the nonces of signed requests
+	// and the failed login counters in the database, shared between pods. The memory store forgets
+	// them on restart and is only accepted when APP_ENV is local.
+	JwtRevocationStore         string        `validate:"required,eq=memory|eq=sql" name:"JWT_REVOCATION_STORE"`
+	JwtRevocationPurgeInterval time.Duration `validate:"required" name:"JWT_REVOCATION_PURGE_INTERVAL"`
+	// ClientTokenTTL is the lifetime of client_credentials tokens, they
+	// cannot be refreshed and outlive a revoked client by at most this.
+	ClientTokenTTL time.Duration `validate:"required" name:"CLIENT_TOKEN_TTL"`
+	// SignatureMaxSkew is how far the X-TIMESTAMP of a signed request may be
+	// from the server clock.
+	SignatureMaxSkew time.Duration `validate:"required" name:"SIGNATURE_MAX_SKEW"`
+	// SignatureMaxBodySize is the largest body in bytes a signed request may
+	// send, it is read into memory to be hashed.
+	SignatureMaxBodySize int64 `validate:"required,min=1" name:"SIGNATURE_MAX_BODY_SIZE"`
+	// ResponseSigningKeyFile is the PEM private key that signs the responses
+	// of routes opted in with SignResponse. Optional, GET /settings is only
+	// signed when it is set.
+	ResponseSigningKeyFile string        `validate:"omitempty,file" name:"RESPONSE_SIGNING_KEY_FILE"`
+	ResponseSigningKeyID   string        `name:"RESPONSE_SIGNING_KEY_ID"`
+	PasswordLockoutWindow  time.Duration `validate:"required" name:"PASSWORD_LOCKOUT_WINDOW"`
+	CaptchaEnabled         bool          `name:"CAPTCHA_ENABLED"`
+	CaptchaExpired         time.Duration `validate:"required" name:"CAPTCHA_EXPIRED"`
 }
 
 func AuthConfigInit() *AuthConfig {
+	revocationStore := os.Getenv("JWT_REVOCATION_STORE")
+	if revocationStore == "" {
+		revocationStore = "sql"
+	}
 	return &AuthConfig{
-		JwtSecretAccessToken: []byte(os.Getenv("JWT_SECRET_ACCESS_TOKEN")),
+		JwtSecretAccessToken:       bytesEnv("JWT_SECRET_ACCESS_TOKEN"),
+		JwtLegacyNotAfter:          parseTimeEnv("JWT_LEGACY_NOT_AFTER"),
+		JwtKeysFile:                os.Getenv("JWT_KEYS_FILE"),
+		JwtAccessTokenTTL:          parseDurationEnv("JWT_ACCESS_TOKEN_TTL", "60m"),
+		JwtRefreshTokenTTL:         parseDurationEnv("JWT_REFRESH_TOKEN_TTL", "168h"),
+		JwtSessionTTL:              parseDurationEnv("JWT_SESSION_TTL", "720h"),
+		JwtRevocationStore:         revocationStore,
+		JwtRevocationPurgeInterval: parseDurationEnv("JWT_REVOCATION_PURGE_INTERVAL", "10m"),
+		ClientTokenTTL:             parseDurationEnv("CLIENT_TOKEN_TTL", "15m"),
+		SignatureMaxSkew:           parseDurationEnv("SIGNATURE_MAX_SKEW", "5m"),
+		SignatureMaxBodySize:       int64(parseIntEnv("SIGNATURE_MAX_BODY_SIZE", 1<<20)),
+		ResponseSigningKeyFile:     os.Getenv("RESPONSE_SIGNING_KEY_FILE"),
+		ResponseSigningKeyID:       os.Getenv("RESPONSE_SIGNING_KEY_ID"),
+		PasswordLockoutWindow:      parseDurationEnv("PASSWORD_LOCKOUT_WINDOW", "30m"),
+		CaptchaEnabled:             os.Getenv("CAPTCHA_ENABLED") != "False",
+		CaptchaExpired:             parseDurationEnv("CAPTCHA_EXPIRED", "5m"),
 	}
 }
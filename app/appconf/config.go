@@ -15,6 +15,9 @@ type Config struct {
 	NotificationServiceConfig *NotificationServiceConfig
 	OTPConfig                 *OTPConfig
 	CustomerServiceConfig     *CustomerServiceConfig
+	StorageConfig             *StorageConfig
+	WorkerConfig              *WorkerConfig
+	OutboxConfig              *OutboxConfig
 }
 
 func (c Config) IsStaging() bool {
@@ -39,6 +42,9 @@ func InitAppConfig(validate *xvalidator.Validator) *Config {
 		NotificationServiceConfig: NotificationServiceConfigInit(),
 		OTPConfig:                 OTPConfigInit(),
 		CustomerServiceConfig:     CustomerServiceConfigInit(),
+		StorageConfig:             StorageConfigInit(),
+		WorkerConfig:              WorkerConfigInit(),
+		OutboxConfig:              OutboxConfigInit(),
 	}
 
 	// NOTIFICATION SERVICE CONFIG
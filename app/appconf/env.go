@@ -0,0 +1,57 @@
+package appconf
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// parseIntEnv reads an integer env var, falling back to def when unset.
+func parseIntEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", name, err)
+	}
+	return result
+}
+
+// parseDurationEnv reads a duration env var, falling back to def when unset.
+func parseDurationEnv(name, def string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		value = def
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", name, err)
+	}
+	return duration
+}
+
+// parseTimeEnv reads an RFC 3339 env var, the zero time when unset.
+func parseTimeEnv(name string) time.Time {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", name, err)
+	}
+	return t
+}
+
+// bytesEnv reads an env var as bytes, nil when unset so required checks fail.
+func bytesEnv(name string) []byte {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	return []byte(value)
+}
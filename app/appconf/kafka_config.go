@@ -2,29 +2,80 @@ package appconf
 
 import (
 	"os"
+	"strconv"
 	"strings"
 )
 
+const (
+	KafkaProtocolPlain       = "PLAIN"
+	KafkaProtocolSSL         = "SSL"
+	KafkaProtocolSASLPlain   = "SASL_PLAIN"
+	KafkaProtocolSASLSSL     = "SASL_SSL"
+	KafkaProtocolSCRAMSHA256 = "SCRAM_SHA_256"
+	KafkaProtocolSCRAMSHA512 = "SCRAM_SHA_512"
+)
+
 type KafkaConfig struct {
-	KafkaSecurityProtocol    string   `validate:"required,eq=SASL_SSL|eq=SASL_PLAIN|eq=PLAIN" name:"KAFKA_SECURITY_PROTOCOL"`
-	KafkaUsername            string   `validate:"required" name:"KAFKA_USERNAME"`
-	KafkaPassword            string   `validate:"required" name:"KAFKA_PASSWORD"`
-	KafkaBroker              []string `validate:"required" name:"KAFKA_BROKER"`
-	KafkaGroupId             string   `validate:"required" name:"KAFKA_GROUP_ID"`
-	KafkaUpdateCustomerTopic string   `validate:"required" name:"KAFKA_UPDATE_CUSTOMER_TOPIC"`
+	KafkaSecurityProtocol string `validate:"required,eq=PLAIN|eq=SSL|eq=SASL_PLAIN|eq=SASL_SSL|eq=SCRAM_SHA_256|eq=SCRAM_SHA_512" name:"KAFKA_SECURITY_PROTOCOL"`
+	// Credentials are only used by the SASL and SCRAM protocols.
+	KafkaUsername string   `validate:"required_unless=KafkaSecurityProtocol PLAIN KafkaSecurityProtocol SSL" name:"KAFKA_USERNAME"`
+	KafkaPassword string   `validate:"required_unless=KafkaSecurityProtocol PLAIN KafkaSecurityProtocol SSL" name:"KAFKA_PASSWORD"`
+	KafkaBrokers  []string `validate:"required,dive,hostname_port" name:"KAFKA_BROKERS"`
+	// KafkaTLSEnabled is always on for SSL and SASL_SSL, set KAFKA_TLS_ENABLED
+	// to run SCRAM or SASL_PLAIN over TLS.
+	KafkaTLSEnabled bool `name:"KAFKA_TLS_ENABLED"`
+	// KafkaTLSCAFile is a PEM bundle trusted on top of the system roots, for
+	// clusters signed by a private CA.
+	KafkaTLSCAFile string `validate:"omitempty,file" name:"KAFKA_TLS_CA_FILE"`
+	// KafkaTLSCertFile and KafkaTLSKeyFile are the client certificate for
+	// mutual TLS, both or neither must be set.
+	KafkaTLSCertFile   string `validate:"required_with=KafkaTLSKeyFile,omitempty,file" name:"KAFKA_TLS_CERT_FILE"`
+	KafkaTLSKeyFile    string `validate:"required_with=KafkaTLSCertFile,omitempty,file" name:"KAFKA_TLS_KEY_FILE"`
+	KafkaTLSServerName string `name:"KAFKA_TLS_SERVER_NAME"`
+
+	KafkaGroupId             string `validate:"required" name:"KAFKA_GROUP_ID"`
+	KafkaUpdateCustomerTopic string `validate:"required" name:"KAFKA_UPDATE_CUSTOMER_TOPIC"`
+	// KafkaUpdateCustomerDLQTopic receives update customer events that could
+	// not be processed, empty skips them.
+	KafkaUpdateCustomerDLQTopic string `name:"KAFKA_UPDATE_CUSTOMER_DLQ_TOPIC"`
+	// Topics published through the outbox, an empty topic disables the event.
+	KafkaSettingsChangedTopic   string `name:"KAFKA_SETTINGS_CHANGED_TOPIC"`
+	KafkaAccountRegisteredTopic string `name:"KAFKA_ACCOUNT_REGISTERED_TOPIC"`
 }
 
 func KafkaConfigInit() *KafkaConfig {
-	brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
-	if len(brokers) == 0 {
-		brokers = nil
+	// Split returns [""] for an empty variable, keep only real entries so
+	// the required check fires.
+	var brokers []string
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	protocol := os.Getenv("KAFKA_SECURITY_PROTOCOL")
+	tlsEnabled, _ := strconv.ParseBool(os.Getenv("KAFKA_TLS_ENABLED"))
+	if protocol == KafkaProtocolSSL || protocol == KafkaProtocolSASLSSL {
+		tlsEnabled = true
 	}
+
 	return &KafkaConfig{
-		KafkaSecurityProtocol:    os.Getenv("KAFKA_SECURITY_PROTOCOL"),
-		KafkaUsername:            os.Getenv("KAFKA_USERNAME"),
-		KafkaPassword:            os.Getenv("KAFKA_PASSWORD"),
-		KafkaBroker:              brokers,
+		KafkaSecurityProtocol: protocol,
+		KafkaUsername:         os.Getenv("KAFKA_USERNAME"),
+		KafkaPassword:         os.Getenv("KAFKA_PASSWORD"),
+		KafkaBrokers:          brokers,
+		KafkaTLSEnabled:       tlsEnabled,
+		KafkaTLSCAFile:        os.Getenv("KAFKA_TLS_CA_FILE"),
+		KafkaTLSCertFile:      os.Getenv("KAFKA_TLS_CERT_FILE"),
+		KafkaTLSKeyFile:       os.Getenv("KAFKA_TLS_KEY_FILE"),
+		KafkaTLSServerName:    os.Getenv("KAFKA_TLS_SERVER_NAME"),
+
 		KafkaGroupId:             os.Getenv("KAFKA_GROUP_ID"),
 		KafkaUpdateCustomerTopic: os.Getenv("KAFKA_UPDATE_CUSTOMER_TOPIC"),
+
+		KafkaUpdateCustomerDLQTopic: os.Getenv("KAFKA_UPDATE_CUSTOMER_DLQ_TOPIC"),
+
+		KafkaSettingsChangedTopic:   os.Getenv("KAFKA_SETTINGS_CHANGED_TOPIC"),
+		KafkaAccountRegisteredTopic: os.Getenv("KAFKA_ACCOUNT_REGISTERED_TOPIC"),
 	}
 }
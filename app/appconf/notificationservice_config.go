@@ -1,13 +1,18 @@
 package appconf
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 type NotificationServiceConfig struct {
-	SendEmailOTPURL           string `validate:"required" name:"NOTIFICATION_SERVICE_SEND_EMAIL_URL"`
-	SendSMSOTPURL             string `validate:"required" name:"NOTIFICATION_SERVICE_SEND_SMS_URL"`
-	TemplateEmailOTP          string `validate:"required" name:"OTP_EMAIL_TEMPLATE_ID"`
-	TemplateSMSOTP            string `validate:"required" name:"OTP_SMS_TEMPLATE_ID"`
-	NotificationServiceAPIKey string `validate:"required" name:"NOTIFICATION_SERVICE_API_KEY"`
+	SendEmailOTPURL           string        `validate:"required" name:"NOTIFICATION_SERVICE_SEND_EMAIL_URL"`
+	SendSMSOTPURL             string        `validate:"required" name:"NOTIFICATION_SERVICE_SEND_SMS_URL"`
+	TemplateEmailOTP          string        `validate:"required" name:"OTP_EMAIL_TEMPLATE_ID"`
+	TemplateSMSOTP            string        `validate:"required" name:"OTP_SMS_TEMPLATE_ID"`
+	NotificationServiceAPIKey string        `validate:"required" name:"NOTIFICATION_SERVICE_API_KEY"`
+	MaxRetries                int           `validate:"min=0" name:"NOTIFICATION_SERVICE_MAX_RETRIES"`
+	RetryBackoff              time.Duration `name:"NOTIFICATION_SERVICE_RETRY_BACKOFF"`
 }
 
 func NotificationServiceConfigInit() *NotificationServiceConfig {
@@ -17,5 +22,7 @@ func NotificationServiceConfigInit() *NotificationServiceConfig {
 		TemplateEmailOTP:          os.Getenv("OTP_EMAIL_TEMPLATE_ID"),
 		TemplateSMSOTP:            os.Getenv("OTP_SMS_TEMPLATE_ID"),
 		NotificationServiceAPIKey: os.Getenv("NOTIFICATION_SERVICE_API_KEY"),
+		MaxRetries:                parseIntEnv("NOTIFICATION_SERVICE_MAX_RETRIES", 2),
+		RetryBackoff:              parseDurationEnv("NOTIFICATION_SERVICE_RETRY_BACKOFF", "500ms"),
 	}
 }
@@ -12,6 +12,8 @@ type OTPConfig struct {
 	TemplateSMSOTPRegis   string        `validate:"required" name:"SEND_OTP_SMS_TEMPLATE_REGIS_ID"`
 	OTPRegisExpired       time.Duration `validate:"required" name:"SMS_OTP_REGIS_EXPIRED"`
 	TemplateEmailOTPRegis string        `validate:"required" name:"SEND_OTP_EMAIL_TEMPLATE_REGIS_ID"`
+	OTPMaxAttempts        int           `validate:"required,min=1" name:"OTP_MAX_ATTEMPTS"`
+	OTPResendCooldown     time.Duration `name:"OTP_RESEND_COOLDOWN"`
 }
 
 func OTPConfigInit() *OTPConfig {
@@ -34,5 +36,7 @@ func OTPConfigInit() *OTPConfig {
 		TemplateEmailOTPRegis: os.Getenv("SEND_OTP_EMAIL_TEMPLATE_REGIS_ID"),
 		OTPRegisExpired:       OTPRegisExpired,
 		TemplateSMSOTPRegis:   os.Getenv("SEND_OTP_SMS_TEMPLATE_REGIS_ID"),
+		OTPMaxAttempts:        parseIntEnv("OTP_MAX_ATTEMPTS", 5),
+		OTPResendCooldown:     parseDurationEnv("OTP_RESEND_COOLDOWN", "1m"),
 	}
 }
@@ -0,0 +1,29 @@
+package appconf
+
+import (
+	"time"
+)
+
+type OutboxConfig struct {
+	OutboxPollInterval time.Duration `name:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatchSize    int           `validate:"min=1" name:"OUTBOX_BATCH_SIZE"`
+	OutboxMaxAttempts  int           `validate:"min=0" name:"OUTBOX_MAX_ATTEMPTS"`
+	OutboxRetryBackoff time.Duration `name:"OUTBOX_RETRY_BACKOFF"`
+	OutboxLeaseTTL     time.Duration `validate:"required" name:"OUTBOX_LEASE_TTL"`
+	// OutboxRetention is how long delivered messages are kept, the purge
+	// runs every OutboxPurgeInterval.
+	OutboxRetention     time.Duration `validate:"required" name:"OUTBOX_RETENTION"`
+	OutboxPurgeInterval time.Duration `validate:"required" name:"OUTBOX_PURGE_INTERVAL"`
+}
+
+func OutboxConfigInit() *OutboxConfig {
+	return &OutboxConfig{
+		OutboxPollInterval:  parseDurationEnv("OUTBOX_POLL_INTERVAL", "1s"),
+		OutboxBatchSize:     parseIntEnv("OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:   parseIntEnv("OUTBOX_MAX_ATTEMPTS", 12),
+		OutboxRetryBackoff:  parseDurationEnv("OUTBOX_RETRY_BACKOFF", "1s"),
+		OutboxLeaseTTL:      parseDurationEnv("OUTBOX_LEASE_TTL", "30s"),
+		OutboxRetention:     parseDurationEnv("OUTBOX_RETENTION", "168h"),
+		OutboxPurgeInterval: parseDurationEnv("OUTBOX_PURGE_INTERVAL", "1h"),
+	}
+}
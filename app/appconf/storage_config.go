@@ -0,0 +1,35 @@
+package appconf
+
+import (
+	"os"
+	"strconv"
+)
+
+type StorageConfig struct {
+	StorageDriver    string `validate:"required,eq=local|eq=s3" name:"STORAGE_DRIVER"`
+	StoragePublicURL string `name:"STORAGE_PUBLIC_URL"`
+	S3Endpoint       string `validate:"required_if=StorageDriver s3" name:"S3_ENDPOINT"`
+	S3Region         string `name:"S3_REGION"`
+	S3Bucket         string `validate:"required_if=StorageDriver s3" name:"S3_BUCKET"`
+	S3AccessKey      string `validate:"required_if=StorageDriver s3" name:"S3_ACCESS_KEY"`
+	S3SecretKey      string `validate:"required_if=StorageDriver s3" name:"S3_SECRET_KEY"`
+	S3UseSSL         bool   `name:"S3_USE_SSL"`
+}
+
+func StorageConfigInit() *StorageConfig {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "local"
+	}
+	useSSL, _ := strconv.ParseBool(os.Getenv("S3_USE_SSL"))
+	return &StorageConfig{
+		StorageDriver:    driver,
+		StoragePublicURL: os.Getenv("STORAGE_PUBLIC_URL"),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3Region:         os.Getenv("S3_REGION"),
+		S3Bucket:         os.Getenv("S3_BUCKET"),
+		S3AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:      os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:         useSSL,
+	}
+}
@@ -0,0 +1,73 @@
+package appconf
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type WorkerConfig struct {
+	WorkerConcurrency int `validate:"min=1" name:"WORKER_CONCURRENCY"`
+	// WorkerTopicConcurrency overrides WorkerConcurrency per topic, read from
+	// WORKER_TOPIC_CONCURRENCY as "topic:count,topic:count".
+	WorkerTopicConcurrency map[string]int `name:"WORKER_TOPIC_CONCURRENCY"`
+	WorkerHealthPort       string         `validate:"required" name:"WORKER_HEALTH_PORT"`
+	WorkerDrainTimeout     time.Duration  `name:"WORKER_DRAIN_TIMEOUT"`
+	// WorkerRunWithHTTP runs the Kafka consumers inside the http command as
+	// well. Off by default, every http replica would start them; turn it on
+	// only for a single replica without a worker deployment. The outbox relay
+	// and the purges run in the http command either way.
+	WorkerRunWithHTTP bool `name:"WORKER_RUN_WITH_HTTP"`
+	// WorkerMaxRetries before a failing message goes to the dead-letter
+	// topic. Zero retries until it succeeds and blocks the partition
+	// meanwhile, it has to be set explicitly.
+	WorkerMaxRetries      int           `validate:"min=0" name:"WORKER_MAX_RETRIES"`
+	WorkerRetryMinBackoff time.Duration `name:"WORKER_RETRY_MIN_BACKOFF"`
+	WorkerRetryMaxBackoff time.Duration `name:"WORKER_RETRY_MAX_BACKOFF"`
+	// WorkerAdminToken guards pausing and resuming consumers on the health
+	// port, empty disables it.
+	WorkerAdminToken string `name:"WORKER_ADMIN_TOKEN"`
+}
+
+// Concurrency returns the number of consumer instances for topic.
+func (c WorkerConfig) Concurrency(topic string) int {
+	if concurrency, ok := c.WorkerTopicConcurrency[topic]; ok {
+		return concurrency
+	}
+	return c.WorkerConcurrency
+}
+
+func WorkerConfigInit() *WorkerConfig {
+	topicConcurrency := map[string]int{}
+	for _, item := range strings.Split(os.Getenv("WORKER_TOPIC_CONCURRENCY"), ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		topic, value, found := strings.Cut(item, ":")
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || err != nil || count < 1 {
+			logrus.Fatalf("invalid WORKER_TOPIC_CONCURRENCY entry %q", item)
+		}
+		topicConcurrency[strings.TrimSpace(topic)] = count
+	}
+
+	healthPort := os.Getenv("WORKER_HEALTH_PORT")
+	if healthPort == "" {
+		healthPort = "8081"
+	}
+
+	return &WorkerConfig{
+		WorkerConcurrency:      parseIntEnv("WORKER_CONCURRENCY", 1),
+		WorkerTopicConcurrency: topicConcurrency,
+		WorkerHealthPort:       healthPort,
+		WorkerDrainTimeout:     parseDurationEnv("WORKER_DRAIN_TIMEOUT", "30s"),
+		WorkerRunWithHTTP:      os.Getenv("WORKER_RUN_WITH_HTTP") == "True",
+		WorkerMaxRetries:       parseIntEnv("WORKER_MAX_RETRIES", 10),
+		WorkerRetryMinBackoff:  parseDurationEnv("WORKER_RETRY_MIN_BACKOFF", "1s"),
+		WorkerRetryMaxBackoff:  parseDurationEnv("WORKER_RETRY_MAX_BACKOFF", "1m"),
+		WorkerAdminToken:       os.Getenv("WORKER_ADMIN_TOKEN"),
+	}
+}
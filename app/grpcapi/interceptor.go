@@ -0,0 +1,92 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"boiler-plate/internal/base/app"
+	"boiler-plate/pkg/policy"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the gRPC metadata equivalent of X-API-Request-ID.
+const RequestIDHeader = "x-api-request-id"
+
+// requestIDInterceptor reads or generates the request ID, stores it on the
+// context and echoes it in the response header.
+func requestIDInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	requestID := firstMetadata(ctx, RequestIDHeader)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	ctx = app.WithRequestID(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logrus.Infoln(fmt.Sprintf("REQUEST ID: %s , METHOD: %s , CODE: %s , LATENCY: %vms",
+		requestID, info.FullMethod, status.Code(err), time.Since(start).Milliseconds()))
+	return resp, err
+}
+
+// recoveryInterceptor turns a panic into codes.Internal like the recover in
+// UserRunAction.
+func recoveryInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if err0 := recover(); err0 != nil {
+			logrus.Errorln(err0)
+			err = status.Error(codes.Internal, "Request is halted unexpectedly, please contact the administrator.")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// authInterceptor validates the bearer token in the "authorization" metadata
+// the same way as UserRunAction. Methods in public skip authentication.
+func (s *GrpcServe) authInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if s.public[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	tokenString := strings.Split(firstMetadata(ctx, "authorization"), " ")[1:]
+	if len(tokenString) == 0 {
+		logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized", app.RequestIDFromContext(ctx)))
+		return nil, status.Error(codes.Unauthenticated, "request does not contain an access token")
+	}
+
+	claims, err := s.base.ParseAccessToken(ctx, tokenString[0])
+	if err != nil {
+		logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized, %v", app.RequestIDFromContext(ctx), err))
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	if err := policy.EvaluateAll(policy.SubjectFromClaims(claims), s.policies[info.FullMethod]); err != nil {
+		logrus.Warnln(fmt.Sprintf("REQUEST ID: %s , message: Forbidden, %v", app.RequestIDFromContext(ctx), err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return handler(app.WithClaims(ctx, claims), req)
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"fmt"
+	"net"
+
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/base/handler"
+	settingsHandler "boiler-plate/internal/settings/handler"
+	"boiler-plate/pkg/jwt"
+	settingsv1 "boiler-plate/pkg/pb/settings/v1"
+	"boiler-plate/pkg/policy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type GrpcServe struct {
+	server *grpc.Server
+	base   *handler.BaseHTTPHandler
+	// public lists the full method names callable without a token, the
+	// counterpart of GuestRoute.
+	public map[string]bool
+	// policies guard full method names like the policies of UserRoute.
+	policies map[string][]policy.Policy
+}
+
+func New(base *handler.BaseHTTPHandler, settings *settingsHandler.GRPCHandler) *GrpcServe {
+	s := &GrpcServe{
+		base: base,
+		public: map[string]bool{
+			settingsv1.SettingsService_FindSettings_FullMethodName: true,
+		},
+		policies: map[string][]policy.Policy{
+			settingsv1.SettingsService_UpdateSettings_FullMethodName: {policy.Roles(jwt.UserRoleAdmin)},
+			settingsv1.SettingsService_ListRevisions_FullMethodName:  {policy.Roles(jwt.UserRoleAdmin)},
+		},
+	}
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestIDInterceptor,
+		recoveryInterceptor,
+		s.authInterceptor,
+	))
+
+	settingsv1.RegisterSettingsServiceServer(s.server, settings)
+	reflection.Register(s.server)
+	return s
+}
+
+func (s *GrpcServe) Run(config *appconf.Config) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", config.AppEnvConfig.GrpcPort))
+	if err != nil {
+		return err
+	}
+	return s.server.Serve(listener)
+}
+
+// Stop waits for in flight calls to finish.
+func (s *GrpcServe) Stop() {
+	s.server.GracefulStop()
+}
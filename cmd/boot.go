@@ -6,15 +6,52 @@ import (
 	"io"
 	"log"
 	"os"
+	"time"
 
 	appConfiguration "boiler-plate/app/appconf"
 
+	AccountHandler "boiler-plate/internal/account/handler"
+	accountRepo "boiler-plate/internal/account/repository"
+	AccountService "boiler-plate/internal/account/service"
+	AuthHandler "boiler-plate/internal/auth/handler"
+	authRepo "boiler-plate/internal/auth/repository"
+	AuthService "boiler-plate/internal/auth/service"
+	ClientHandler "boiler-plate/internal/client/handler"
+	clientRepo "boiler-plate/internal/client/repository"
+	ClientService "boiler-plate/internal/client/service"
+	CustomerConsumer "boiler-plate/internal/customer/consumer"
+	customerRepo "boiler-plate/internal/customer/repository"
+	CustomerService "boiler-plate/internal/customer/service"
+	"boiler-plate/internal/events"
+	InvestorCategoryHandler "boiler-plate/internal/investorcategory/handler"
+	investorCategoryRepo "boiler-plate/internal/investorcategory/repository"
+	InvestorCategoryService "boiler-plate/internal/investorcategory/service"
+	OTPHandler "boiler-plate/internal/otp/handler"
+	otpRepo "boiler-plate/internal/otp/repository"
+	OTPService "boiler-plate/internal/otp/service"
+	RegistrationHandler "boiler-plate/internal/registration/handler"
+	RegistrationService "boiler-plate/internal/registration/service"
 	tempHandler "boiler-plate/internal/settings/handler"
 	settingsRepo "boiler-plate/internal/settings/repository"
 	SettingsService "boiler-plate/internal/settings/service"
+	VerificationHandler "boiler-plate/internal/verification/handler"
+	VerificationService "boiler-plate/internal/verification/service"
+	"boiler-plate/pkg/broker/kafkaservice"
+	"boiler-plate/pkg/captcha"
+	"boiler-plate/pkg/consumer"
+	"boiler-plate/pkg/customerservice"
 	"boiler-plate/pkg/db"
+	"boiler-plate/pkg/event"
 	"boiler-plate/pkg/httpclient"
+	"boiler-plate/pkg/jwt"
 	"boiler-plate/pkg/migration"
+	"boiler-plate/pkg/notification"
+	"boiler-plate/pkg/outbox"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/revocation"
+	"boiler-plate/pkg/signature"
+	"boiler-plate/pkg/storage"
+	"boiler-plate/pkg/worker"
 	"boiler-plate/pkg/xvalidator"
 
 	"github.com/go-playground/validator/v10"
@@ -27,11 +64,41 @@ var (
 	appConf         *appConfiguration.Config
 	baseHandler     *handler.BaseHTTPHandler
 	settingsHandler *tempHandler.HTTPHandler
+	settingsGRPC    *tempHandler.GRPCHandler
+	passwordEngine  *password.Engine
+	jwtKeys         *jwt.KeySet
+	revocations     revocation.Store
+	// sqlRevocations is set when JWT_REVOCATION_STORE is sql, the worker
+	// purges its expired rows.
+	sqlRevocations *revocation.SQLStore
+	// nonces remembers signed requests, in the database with sqlRevocations.
+	nonces    signature.NonceStore
+	sqlNonces *signature.SQLNonceStore
+	// lockouts counts failed logins, in the database with sqlRevocations.
+	lockouts    password.LockoutStore
+	sqlLockouts *password.SQLLockoutStore
+
+	accountHandler          *AccountHandler.HTTPHandler
+	authHandler             *AuthHandler.HTTPHandler
+	clientHandler           *ClientHandler.HTTPHandler
+	registrationHandler     *RegistrationHandler.HTTPHandler
+	verificationHandler     *VerificationHandler.HTTPHandler
+	otpHandler              *OTPHandler.HTTPHandler
+	investorCategoryHandler *InvestorCategoryHandler.HTTPHandler
+
+	customerService  CustomerService.Service
+	consumerRegistry = consumer.NewRegistry()
+	eventRegistry    *event.Registry
+	eventOutbox      *outbox.Outbox
 
 	sqlClientRepo *db.SQLClientRepository
+	fileStorage   storage.Storage
 	validate      *validator.Validate
 	httpClient    httpclient.Client
+	notifier      *notification.Client
+	kafkaService  kafkaservice.Broker
 	xvalidate     *xvalidator.Validator
+	captchaStore  *captcha.Captcha
 )
 
 func initHttpclient() {
@@ -46,19 +113,258 @@ func initHTTP() {
 
 	// appConf.MysqlTZ = postgresClientRepo.TZ
 
-	baseHandler = handler.NewBaseHTTPHandler(sqlClientRepo.DB, appConf, sqlClientRepo, httpClient)
+	baseHandler = handler.NewBaseHTTPHandler(sqlClientRepo.DB, appConf, sqlClientRepo, httpClient, jwtKeys, revocations)
 
-	settingsRepo := settingsRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
-	settingsService := SettingsService.NewService(appConf, settingsRepo, validate)
+	settingsRepo := settingsRepo.NewRepository(
+		sqlClientRepo.DB, sqlClientRepo, eventOutbox, appConf.KafkaConfig.KafkaSettingsChangedTopic,
+	)
+	settingsService := SettingsService.NewService(appConf, settingsRepo, xvalidate, fileStorage)
 	settingsHandler = tempHandler.NewHTTPHandler(baseHandler, settingsService)
+	settingsGRPC = tempHandler.NewGRPCHandler(settingsService)
+	passwordEngine = password.NewEngine(settingsService, lockouts, appConf.AuthConfig.PasswordLockoutWindow)
+
+	accountRepo := accountRepo.NewRepository(
+		sqlClientRepo.DB, sqlClientRepo, eventOutbox, appConf.KafkaConfig.KafkaAccountRegisteredTopic,
+	)
+	authRepo := authRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
+	authService := AuthService.NewService(appConf, authRepo, accountRepo, xvalidate, jwtKeys, revocations, passwordEngine)
+	authHandler = AuthHandler.NewHTTPHandler(baseHandler, authService)
+
+	clientRepo := clientRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
+	clientService := ClientService.NewService(appConf, clientRepo, xvalidate, jwtKeys)
+	clientHandler = ClientHandler.NewHTTPHandler(baseHandler, clientService)
+	baseHandler.Signatures = signature.NewVerifier(
+		ClientService.NewKeyRegistry(clientRepo), appConf.AuthConfig.SignatureMaxSkew, nonces,
+	)
+	if keyFile := appConf.AuthConfig.ResponseSigningKeyFile; keyFile != "" {
+		signer, err := signature.LoadResponseSigner(appConf.AuthConfig.ResponseSigningKeyID, keyFile)
+		if err != nil {
+			logrus.Fatalf("failed to load response signing key: %v", err)
+		}
+		baseHandler.ResponseSigner = signer
+	}
+
+	accountService := AccountService.NewService(
+		appConf, accountRepo, xvalidate, passwordEngine, captchaStore, authService,
+	)
+	accountHandler = AccountHandler.NewHTTPHandler(baseHandler, accountService)
+
+	otpRepo := otpRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
+	otpService := OTPService.NewService(appConf, otpRepo, accountRepo, xvalidate, notifier)
+	otpHandler = OTPHandler.NewHTTPHandler(baseHandler, otpService)
+
+	verificationService := VerificationService.NewService(appConf, accountRepo, otpService, xvalidate)
+	verificationHandler = VerificationHandler.NewHTTPHandler(baseHandler, verificationService)
+
+	registrationService := RegistrationService.NewService(
+		appConf, accountRepo, verificationService, xvalidate, passwordEngine, captchaStore,
+	)
+	registrationHandler = RegistrationHandler.NewHTTPHandler(baseHandler, registrationService)
+
+	investorCategoryRepo := investorCategoryRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
+	investorCategoryService := InvestorCategoryService.NewService(appConf, investorCategoryRepo, xvalidate)
+	investorCategoryHandler = InvestorCategoryHandler.NewHTTPHandler(baseHandler, investorCategoryService)
+
+	customerClient := customerservice.New(httpClient, &customerservice.Config{
+		DetailInvestorURL: appConf.CustomerServiceConfig.CustomerSvcGetDetailInvestorURL,
+		APIKey:            appConf.CustomerServiceConfig.CustomerSvcAPIKey,
+	})
+	customerRepo := customerRepo.NewRepository(sqlClientRepo.DB, sqlClientRepo)
+	customerService = CustomerService.NewService(appConf, customerRepo, customerClient)
+}
+
+// registerConsumers adds every Kafka consumer to w, used by the worker
+// command and by the http command when WORKER_RUN_WITH_HTTP is on.
+func registerConsumers(w *worker.Worker) {
+	topic, groupID := appConf.KafkaConfig.KafkaUpdateCustomerTopic, appConf.KafkaConfig.KafkaGroupId
+	customerConfig := consumerConfig(topic, appConf.KafkaConfig.KafkaUpdateCustomerDLQTopic)
+	customerConfig.EventType = events.CustomerUpdated
+	w.Register(topic, appConf.WorkerConfig.Concurrency(topic), func() worker.Runner {
+		return CustomerConsumer.NewUpdateCustomerConsumer(
+			kafkaService.NewReader(topic, groupID), customerService, customerConfig, consumerRegistry,
+		)
+	})
+}
+
+// registerMaintenance adds the outbox relay and the purges of the SQL stores
+// to w. Both the http and the worker command run them, so a deployment
+// without workers still publishes its events and keeps the tables small.
+func registerMaintenance(w *worker.Worker) {
+	// Every process runs a relay, the lease lets one of them publish at a
+	// time so the per key order of the outbox holds. The purges are plain
+	// deletes, running them on every replica only repeats the work.
+	w.Register("outbox-relay", 1, func() worker.Runner {
+		return outbox.NewRelay(sqlClientRepo.DB, kafkaService, &outbox.RelayConfig{
+			PollInterval: appConf.OutboxConfig.OutboxPollInterval,
+			BatchSize:    appConf.OutboxConfig.OutboxBatchSize,
+			MaxAttempts:  appConf.OutboxConfig.OutboxMaxAttempts,
+			RetryBackoff: appConf.OutboxConfig.OutboxRetryBackoff,
+			LeaseTTL:     appConf.OutboxConfig.OutboxLeaseTTL,
+		})
+	})
+	w.Register("outbox-purge", 1, func() worker.Runner {
+		return outbox.NewPurger(
+			sqlClientRepo.DB, appConf.OutboxConfig.OutboxRetention, appConf.OutboxConfig.OutboxPurgeInterval,
+		)
+	})
+
+	if sqlRevocations != nil {
+		w.Register("revocation-purge", 1, func() worker.Runner {
+			return sqlRevocations
+		})
+	}
+	if sqlNonces != nil {
+		w.Register("signature-nonce-purge", 1, func() worker.Runner {
+			return sqlNonces
+		})
+	}
+	if sqlLockouts != nil {
+		w.Register("password-lockout-purge", 1, func() worker.Runner {
+			return sqlLockouts
+		})
+	}
+}
 
+func consumerConfig(topic, deadLetterTopic string) *consumer.Config {
+	return &consumer.Config{
+		Topic:           topic,
+		DeadLetterTopic: deadLetterTopic,
+		Publisher:       kafkaService,
+		MaxRetries:      appConf.WorkerConfig.WorkerMaxRetries,
+		MinBackoff:      appConf.WorkerConfig.WorkerRetryMinBackoff,
+		MaxBackoff:      appConf.WorkerConfig.WorkerRetryMaxBackoff,
+		Registry:        eventRegistry,
+	}
 }
 
 func initInfrastructure(config *appConfiguration.Config) {
+	initStorage(config)
 	initSQL(config)
 	initHttpclient()
+	initNotification(config)
+	initKafka(config)
+	initEvents(config)
+	initCaptcha(config)
+	initJWT(config)
+	initRevocation(config)
 	initLog()
 }
+
+func initRevocation(config *appConfiguration.Config) {
+	if config.AuthConfig.JwtRevocationStore == "sql" {
+		sqlRevocations = revocation.NewSQLStore(sqlClientRepo.DB, config.AuthConfig.JwtRevocationPurgeInterval)
+		revocations = sqlRevocations
+		sqlNonces = signature.NewSQLNonceStore(sqlClientRepo.DB, config.AuthConfig.JwtRevocationPurgeInterval)
+		nonces = sqlNonces
+		sqlLockouts = password.NewSQLLockoutStore(sqlClientRepo.DB, config.AuthConfig.JwtRevocationPurgeInterval)
+		lockouts = sqlLockouts
+		return
+	}
+	// every pod would keep its own list, a token revoked on one pod stays
+	// valid on the others
+	if config.AppEnvConfig.AppEnv != "local" {
+		logrus.Fatalln("JWT_REVOCATION_STORE=memory is only allowed when APP_ENV is local")
+	}
+	revocations = revocation.NewMemoryStore()
+	nonces = signature.NewMemoryNonceStore()
+	lockouts = password.NewMemoryLockoutStore()
+}
+
+// initJWT loads the access token keys. The HS256 secret signs tokens unless a
+// key file is configured; then it only verifies tokens issued before
+// JWT_LEGACY_NOT_AFTER, until they expire.
+func initJWT(config *appConfiguration.Config) {
+	authConfig := config.AuthConfig
+	if authConfig.JwtKeysFile == "" {
+		keys, err := jwt.NewKeySet(authConfig.JwtAccessTokenTTL, jwt.NewHMACKey("", authConfig.JwtSecretAccessToken))
+		if err != nil {
+			logrus.Fatalf("failed to init jwt keys: %v", err)
+		}
+		jwtKeys = keys
+		return
+	}
+
+	keys, err := jwt.LoadKeyFile(authConfig.JwtKeysFile)
+	if err != nil {
+		logrus.Fatalf("failed to load jwt keys: %v", err)
+	}
+	if authConfig.JwtSecretAccessToken != nil {
+		// the cutoff is fixed at the rollout, taking it from the clock would
+		// keep old tokens valid for another TTL after every restart
+		notAfter := authConfig.JwtLegacyNotAfter
+		switch {
+		case notAfter.IsZero():
+			logrus.Fatalln("JWT_LEGACY_NOT_AFTER is required while JWT_SECRET_ACCESS_TOKEN is set with JWT_KEYS_FILE")
+		case notAfter.After(time.Now()):
+			logrus.Fatalln("JWT_LEGACY_NOT_AFTER must not be in the future")
+		case time.Since(notAfter) > authConfig.JwtAccessTokenTTL:
+			logrus.Warnln("tokens signed with JWT_SECRET_ACCESS_TOKEN have expired, the secret can be removed")
+		default:
+			legacy := jwt.NewHMACKey("", authConfig.JwtSecretAccessToken)
+			legacy.NotAfter = notAfter
+			keys = append(keys, legacy)
+		}
+	}
+	if jwtKeys, err = jwt.NewKeySet(authConfig.JwtAccessTokenTTL, keys...); err != nil {
+		logrus.Fatalf("failed to init jwt keys: %v", err)
+	}
+	if _, err := jwtKeys.SigningKey(); err != nil {
+		logrus.Fatalf("failed to init jwt keys: %v", err)
+	}
+}
+
+func initNotification(config *appConfiguration.Config) {
+	notifier = notification.New(httpClient, &notification.Config{
+		EmailURL:     config.NotificationServiceConfig.SendEmailOTPURL,
+		SMSURL:       config.NotificationServiceConfig.SendSMSOTPURL,
+		APIKey:       config.NotificationServiceConfig.NotificationServiceAPIKey,
+		MaxRetries:   config.NotificationServiceConfig.MaxRetries,
+		RetryBackoff: config.NotificationServiceConfig.RetryBackoff,
+	}, notification.NewSQLDeliveryLog(sqlClientRepo.DB))
+
+	for _, templateID := range []string{
+		config.NotificationServiceConfig.TemplateEmailOTP,
+		config.NotificationServiceConfig.TemplateSMSOTP,
+		config.OTPConfig.TemplateEmailOTPRegis,
+		config.OTPConfig.TemplateSMSOTPRegis,
+	} {
+		notifier.RegisterTemplate(templateID, "otp")
+	}
+}
+
+func initKafka(config *appConfiguration.Config) {
+	kafkaConfig := &kafkaservice.Config{
+		SecurityProtocol: config.KafkaConfig.KafkaSecurityProtocol,
+		Brokers:          config.KafkaConfig.KafkaBrokers,
+		Username:         config.KafkaConfig.KafkaUsername,
+		Password:         config.KafkaConfig.KafkaPassword,
+	}
+	if config.KafkaConfig.KafkaTLSEnabled {
+		kafkaConfig.TLS = &kafkaservice.TLSConfig{
+			CAFile:     config.KafkaConfig.KafkaTLSCAFile,
+			CertFile:   config.KafkaConfig.KafkaTLSCertFile,
+			KeyFile:    config.KafkaConfig.KafkaTLSKeyFile,
+			ServerName: config.KafkaConfig.KafkaTLSServerName,
+		}
+	}
+
+	var err error
+	if kafkaService, err = kafkaservice.New(kafkaConfig); err != nil {
+		logrus.Fatalf("failed to initialize kafka: %v", err)
+	}
+}
+
+func initEvents(config *appConfiguration.Config) {
+	var err error
+	if eventRegistry, err = events.NewRegistry(); err != nil {
+		logrus.Fatalf("failed to load event schemas: %v", err)
+	}
+	eventOutbox = outbox.New(eventRegistry, config.AppEnvConfig.AppName)
+}
+
+func initCaptcha(config *appConfiguration.Config) {
+	captchaStore = captcha.New(config.AuthConfig.CaptchaExpired, config.AuthConfig.CaptchaEnabled)
+}
 func initValidator() {
 	validate = validator.New()
 	xvalidate = xvalidator.NewValidator()
@@ -113,6 +419,24 @@ func initLog() {
 	}
 }
 
+func initStorage(config *appConfiguration.Config) {
+	var err error
+	fileStorage, err = storage.New(&storage.Config{
+		Driver:    config.StorageConfig.StorageDriver,
+		Path:      config.AppEnvConfig.FilePath,
+		PublicURL: config.StorageConfig.StoragePublicURL,
+		Endpoint:  config.StorageConfig.S3Endpoint,
+		Region:    config.StorageConfig.S3Region,
+		Bucket:    config.StorageConfig.S3Bucket,
+		AccessKey: config.StorageConfig.S3AccessKey,
+		SecretKey: config.StorageConfig.S3SecretKey,
+		UseSSL:    config.StorageConfig.S3UseSSL,
+	})
+	if err != nil {
+		logrus.Fatalf("failed to initialize file storage: %v", err)
+	}
+}
+
 func initSQL(config *appConfiguration.Config) {
 
 	//var gConfig *gorm.Config
@@ -130,6 +454,6 @@ func initSQL(config *appConfiguration.Config) {
 
 	sqlClientRepo, _ = db.NewSQLClientRepository(config, gConfig)
 	if config.IsStaging() {
-		migration.Initmigrate(sqlClientRepo.DB)
+		migration.Initmigrate(sqlClientRepo.DB, fileStorage)
 	}
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"boiler-plate/app/grpcapi"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var GrpcCmd = &cobra.Command{
+	Use:   "grpc",
+	Short: "Run gRPC API",
+	Long:  "Run gRPC API with server reflection enabled",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initHTTP()
+
+		server := grpcapi.New(baseHandler, settingsGRPC)
+
+		echan := make(chan error)
+		go func() {
+			echan <- server.Run(appConf)
+		}()
+
+		term := make(chan os.Signal, 1)
+		signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+
+		select {
+		case <-term:
+			logrus.Infoln("signal terminated detected")
+			server.Stop()
+			return nil
+		case err := <-echan:
+			return errors.Wrap(err, "grpc runtime error")
+		}
+	},
+}
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"boiler-plate/app/api"
+	"boiler-plate/pkg/worker"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -22,13 +24,34 @@ var HttpCmd = &cobra.Command{
 		// running open telemetry
 		// cleanup := initTracer()
 		// defer cleanup(context.Background())
-		app := api.New(appConf.AppEnvConfig.AppName, baseHandler, settingsHandler)
+		app := api.New(
+			appConf.AppEnvConfig.AppName, baseHandler, settingsHandler,
+			accountHandler, authHandler, clientHandler, registrationHandler, verificationHandler, otpHandler, investorCategoryHandler,
+		)
 
 		echan := make(chan error)
 		go func() {
 			echan <- app.Run(appConf)
 		}()
 
+		workerCtx, stopWorker := context.WithCancel(context.Background())
+		workerDone := make(chan struct{})
+		w := worker.New(appConf.WorkerConfig.WorkerDrainTimeout)
+		registerMaintenance(w)
+		if appConf.WorkerConfig.WorkerRunWithHTTP {
+			registerConsumers(w)
+		}
+		go func() {
+			defer close(workerDone)
+			if err := w.Run(workerCtx); err != nil {
+				logrus.Errorf("worker runtime error: %v", err)
+			}
+		}()
+		defer func() {
+			stopWorker()
+			<-workerDone
+		}()
+
 		//go func() {
 		//	if err := serverio.Serve(); err != nil {
 		//		logrus.Fatalf("socketio listen error: %s\n", err)
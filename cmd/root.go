@@ -22,6 +22,8 @@ var rootCmd = &cobra.Command{
 // register command
 func init() {
 	rootCmd.AddCommand(HttpCmd)
+	rootCmd.AddCommand(WorkerCmd)
+	rootCmd.AddCommand(GrpcCmd)
 
 	// load environment variable
 	if err := godotenv.Load(); err != nil {
@@ -51,7 +53,10 @@ func init() {
 func Execute() error {
 	cmd, _, err := rootCmd.Find(os.Args[1:])
 
-	if err == nil && cmd.Use == rootCmd.Use && cmd.Flags().Parse(os.Args[1:]) != pflag.ErrHelp {
+	// Only a bare invocation defaults to http, unknown commands are reported
+	// by cobra instead of silently starting the Http API.
+	if err == nil && cmd.Use == rootCmd.Use && cmd.Flags().Parse(os.Args[1:]) != pflag.ErrHelp &&
+		cmd.Flags().NArg() == 0 {
 		args := append([]string{"http"}, os.Args[1:]...)
 		rootCmd.SetArgs(args)
 	}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"boiler-plate/pkg/worker"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var WorkerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run Kafka consumers",
+	Long:  "Run Kafka consumers without the Http API, health is served on WORKER_HEALTH_PORT",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initHTTP()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		w := worker.New(appConf.WorkerConfig.WorkerDrainTimeout)
+		registerConsumers(w)
+		registerMaintenance(w)
+		consumerHandler := consumerRegistry.Handler(appConf.WorkerConfig.WorkerAdminToken)
+		w.Handle("/consumers", consumerHandler)
+		w.Handle("/consumers/", consumerHandler)
+
+		go func() {
+			addr := fmt.Sprintf(":%s", appConf.WorkerConfig.WorkerHealthPort)
+			if err := w.ServeHealth(ctx, addr); err != nil {
+				logrus.Errorf("worker health server error: %v", err)
+			}
+		}()
+
+		if err := w.Run(ctx); err != nil {
+			return errors.Wrap(err, "worker runtime error")
+		}
+		logrus.Infoln("signal terminated detected")
+		return nil
+	},
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AccountTableName         = "accounts"
+	PasswordHistoryTableName = "password_histories"
+
+	VerificationTypeEmail = "email"
+	VerificationTypePhone = "phone_number"
+)
+
+type Account struct {
+	ID                int64          `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Fullname          string         `gorm:"size:150;not null" json:"fullname"`
+	Email             string         `gorm:"size:150;uniqueIndex;not null" json:"email"`
+	Phone             string         `gorm:"size:30;uniqueIndex;not null" json:"phone"`
+	Password          string         `gorm:"size:100;not null" json:"-"`
+	RoleID            int            `json:"role_id"`
+	Role              *Role          `gorm:"foreignKey:RoleID" json:"role,omitempty"`
+	EmailVerifiedAt   *time.Time     `json:"email_verified_at"`
+	PhoneVerifiedAt   *time.Time     `json:"phone_verified_at"`
+	PasswordChangedAt *time.Time     `json:"password_changed_at"`
+	PasswordExpiredAt *time.Time     `json:"password_expired_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (model *Account) TableName() string {
+	return os.Getenv("DB_PREFIX") + AccountTableName
+}
+
+// IsVerified reports whether the email or the phone number was verified.
+func (model *Account) IsVerified() bool {
+	return model.EmailVerifiedAt != nil || model.PhoneVerifiedAt != nil
+}
+
+// IsPasswordExpired reports whether the password expiry set by the password
+// policy has passed.
+func (model *Account) IsPasswordExpired() bool {
+	return model.PasswordExpiredAt != nil && model.PasswordExpiredAt.Before(time.Now())
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in, so
+// "A@x.com" and "a@x.com" are one account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// PasswordHistory keeps previous password hashes for the reuse rule of the
+// password policy.
+type PasswordHistory struct {
+	ID        int64     `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	AccountID int64     `gorm:"index;not null" json:"account_id"`
+	Password  string    `gorm:"size:100;not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (model *PasswordHistory) TableName() string {
+	return os.Getenv("DB_PREFIX") + PasswordHistoryTableName
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email" name:"email"`
+	Password string `json:"password" validate:"required" name:"password"`
+	Captcha  string `json:"captcha" validate:"required" name:"captcha"`
+	Key      string `json:"key" validate:"required" name:"key"`
+	// Recorded on the session, filled by the handler.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type CaptchaResponse struct {
+	Sensitive bool   `json:"sensitive"`
+	Key       string `json:"key"`
+	Img       string `json:"img"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword             string `json:"old_password" validate:"required" name:"old_password"`
+	NewPassword             string `json:"new_password" validate:"required" name:"new_password"`
+	NewPasswordConfirmation string `json:"new_password_confirmation" validate:"required,eqfield=NewPassword" name:"new_password_confirmation"`
+}
+
+// ExpiredPasswordRequest replaces an expired password without a token, the
+// email and old password identify the caller like a login does.
+type ExpiredPasswordRequest struct {
+	Email                   string `json:"email" validate:"required,email" name:"email"`
+	OldPassword             string `json:"old_password" validate:"required" name:"old_password"`
+	NewPassword             string `json:"new_password" validate:"required" name:"new_password"`
+	NewPasswordConfirmation string `json:"new_password_confirmation" validate:"required,eqfield=NewPassword" name:"new_password_confirmation"`
+	Captcha                 string `json:"captcha" validate:"required" name:"captcha"`
+	Key                     string `json:"key" validate:"required" name:"key"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// AccountRegisteredEvent is published once a self registered account is
+// committed, keyed by the account ID.
+type AccountRegisteredEvent struct {
+	AccountID    int64     `json:"account_id"`
+	Fullname     string    `json:"fullname"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone"`
+	RoleID       int       `json:"role_id"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
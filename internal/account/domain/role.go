@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"os"
+)
+
+const (
+	RoleTableName           = "roles"
+	PermissionTableName     = "permissions"
+	RolePermissionTableName = "role_permissions"
+)
+
+type Role struct {
+	ID   int    `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Name string `gorm:"size:50;uniqueIndex;not null" json:"name"`
+}
+
+func (model *Role) TableName() string {
+	return os.Getenv("DB_PREFIX") + RoleTableName
+}
+
+type Permission struct {
+	ID   int    `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Name string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+}
+
+func (model *Permission) TableName() string {
+	return os.Getenv("DB_PREFIX") + PermissionTableName
+}
+
+type RolePermission struct {
+	ID           int         `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	RoleID       int         `gorm:"index;not null" json:"role_id"`
+	PermissionID int         `gorm:"index;not null" json:"permission_id"`
+	Permission   *Permission `gorm:"foreignKey:PermissionID" json:"permission,omitempty"`
+}
+
+func (model *RolePermission) TableName() string {
+	return os.Getenv("DB_PREFIX") + RolePermissionTableName
+}
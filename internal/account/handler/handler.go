@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"boiler-plate/internal/account/domain"
+	AccountService "boiler-plate/internal/account/service"
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+)
+
+type HTTPHandler struct {
+	App            *handler.BaseHTTPHandler
+	AccountService AccountService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, accountService AccountService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:            handler,
+		AccountService: accountService,
+	}
+}
+
+func (h HTTPHandler) Captcha(ctx *app.Context) *server.ResponseInterface {
+	result, exc := h.AccountService.Captcha(ctx)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Login(ctx *app.Context) *server.ResponseInterface {
+	var request domain.LoginRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	request.UserAgent = ctx.Request.UserAgent()
+	request.IPAddress = ctx.ClientIP()
+
+	result, exc := h.AccountService.Login(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) FindProfile(ctx *app.Context) *server.ResponseInterface {
+	id, ok := ctx.SubjectID()
+	if !ok {
+		return h.App.AsException(ctx, exception.Unauthenticated("invalid token subject"))
+	}
+
+	result, exc := h.AccountService.FindProfile(ctx, id)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) ChangePassword(ctx *app.Context) *server.ResponseInterface {
+	id, ok := ctx.SubjectID()
+	if !ok {
+		return h.App.AsException(ctx, exception.Unauthenticated("invalid token subject"))
+	}
+
+	var request domain.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.AccountService.ChangePassword(ctx, id, &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
+
+// ChangeExpiredPassword is a guest route, an expired password gets no token.
+func (h HTTPHandler) ChangeExpiredPassword(ctx *app.Context) *server.ResponseInterface {
+	var request domain.ExpiredPasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.AccountService.ChangeExpiredPassword(ctx, &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"boiler-plate/internal/account/domain"
+	"context"
+	"time"
+)
+
+type Repository interface {
+	FindByID(ctx context.Context, id int64) (*domain.Account, error)
+	FindByEmail(ctx context.Context, email string) (*domain.Account, error)
+	FindByPhone(ctx context.Context, phone string) (*domain.Account, error)
+	Create(ctx context.Context, model *domain.Account) error
+	UpdatePassword(ctx context.Context, model *domain.Account) error
+	MarkVerified(ctx context.Context, id int64, verificationType string, verifiedAt time.Time) error
+	FindPasswordHistory(ctx context.Context, accountID int64, limit int) ([]string, error)
+	FindRoleByName(ctx context.Context, name string) (*domain.Role, error)
+	FindPermissions(ctx context.Context, roleID int) ([]string, error)
+}
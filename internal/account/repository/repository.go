@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"boiler-plate/internal/account/domain"
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/events"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"boiler-plate/pkg/outbox"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db     *gorm.DB
+	base   *baseModel.SQLClientRepository
+	events *outbox.Outbox
+	// registeredTopic receives an AccountRegisteredEvent for every created
+	// account through the outbox, empty disables it.
+	registeredTopic string
+}
+
+func NewRepository(
+	db *gorm.DB, base *baseModel.SQLClientRepository, events *outbox.Outbox, registeredTopic string,
+) Repository {
+	return &Repo{db: db, base: base, events: events, registeredTopic: registeredTopic}
+}
+
+func (r Repo) FindByID(ctx context.Context, id int64) (*domain.Account, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+func (r Repo) FindByEmail(ctx context.Context, email string) (*domain.Account, error) {
+	return r.findOne(ctx, "email = ?", domain.NormalizeEmail(email))
+}
+
+func (r Repo) FindByPhone(ctx context.Context, phone string) (*domain.Account, error) {
+	return r.findOne(ctx, "phone = ?", phone)
+}
+
+func (r Repo) findOne(ctx context.Context, query string, args ...interface{}) (*domain.Account, error) {
+	var (
+		models *domain.Account
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Account{}).
+		Preload("Role").
+		Where(query, args...).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+// Create stores the account, its first password in the history and the
+// registration event.
+func (r Repo) Create(ctx context.Context, model *domain.Account) error {
+	model.Email = domain.NormalizeEmail(model.Email)
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Role").Create(model).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&domain.PasswordHistory{
+			AccountID: model.ID,
+			Password:  model.Password,
+		}).Error; err != nil {
+			return err
+		}
+		return r.events.Enqueue(tx, app.RequestIDFromContext(ctx), &outbox.Event{
+			Topic:   r.registeredTopic,
+			Key:     strconv.FormatInt(model.ID, 10),
+			Type:    events.AccountRegistered,
+			Version: events.AccountRegisteredVersion,
+			Data: &domain.AccountRegisteredEvent{
+				AccountID:    model.ID,
+				Fullname:     model.Fullname,
+				Email:        model.Email,
+				Phone:        model.Phone,
+				RoleID:       model.RoleID,
+				RegisteredAt: model.CreatedAt,
+			},
+		})
+	}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// UpdatePassword stores the password fields of model and appends the new
+// hash to the history.
+func (r Repo) UpdatePassword(ctx context.Context, model *domain.Account) error {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Account{}).
+			Where("id = ?", model.ID).
+			Updates(map[string]interface{}{
+				"password":            model.Password,
+				"password_changed_at": model.PasswordChangedAt,
+				"password_expired_at": model.PasswordExpiredAt,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.PasswordHistory{
+			AccountID: model.ID,
+			Password:  model.Password,
+		}).Error
+	}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (r Repo) MarkVerified(ctx context.Context, id int64, verificationType string, verifiedAt time.Time) error {
+	column := "email_verified_at"
+	if verificationType == domain.VerificationTypePhone {
+		column = "phone_verified_at"
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Account{}).
+		Where("id = ?", id).
+		Update(column, verifiedAt).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// FindPasswordHistory returns the latest password hashes, most recent first.
+func (r Repo) FindPasswordHistory(ctx context.Context, accountID int64, limit int) ([]string, error) {
+	var (
+		hashes []string
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.PasswordHistory{}).
+		Where("account_id = ?", accountID).
+		Order("id desc").
+		Limit(limit).
+		Pluck("password", &hashes).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return hashes, nil
+}
+
+func (r Repo) FindRoleByName(ctx context.Context, name string) (*domain.Role, error) {
+	var (
+		models *domain.Role
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Role{}).
+		Where("name = ?", name).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindPermissions(ctx context.Context, roleID int) ([]string, error) {
+	var (
+		models []domain.RolePermission
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.RolePermission{}).
+		Preload("Permission").
+		Where("role_id = ?", roleID).
+		Find(&models).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+
+	permissions := make([]string, 0, len(models))
+	for _, model := range models {
+		if model.Permission != nil {
+			permissions = append(permissions, model.Permission.Name)
+		}
+	}
+	return permissions, nil
+}
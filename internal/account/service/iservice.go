@@ -0,0 +1,20 @@
+package service
+
+import (
+	"boiler-plate/internal/account/domain"
+	authDomain "boiler-plate/internal/auth/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+)
+
+type Service interface {
+	Captcha(ctx context.Context) (*domain.CaptchaResponse, *exception.Exception)
+	Login(ctx context.Context, req *domain.LoginRequest) (*authDomain.TokenResponse, *exception.Exception)
+	FindProfile(ctx context.Context, id int64) (*domain.Account, *exception.Exception)
+	// ChangePassword sets a new password and ends every session of the
+	// account, the current one included.
+	ChangePassword(ctx context.Context, id int64, req *domain.ChangePasswordRequest) *exception.Exception
+	// ChangeExpiredPassword lets an account whose password expired, and which
+	// therefore cannot log in, set a new one. Its sessions are ended as well.
+	ChangeExpiredPassword(ctx context.Context, req *domain.ExpiredPasswordRequest) *exception.Exception
+}
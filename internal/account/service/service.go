@@ -0,0 +1,200 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/account/domain"
+	"boiler-plate/internal/account/repository"
+	authDomain "boiler-plate/internal/auth/domain"
+	AuthService "boiler-plate/internal/auth/service"
+	"boiler-plate/pkg/captcha"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewService creates new account service
+func NewService(
+	config *appconf.Config, repo repository.Repository, validate *xvalidator.Validator,
+	passwordEngine *password.Engine, captcha *captcha.Captcha, tokens AuthService.Service,
+) Service {
+	return &service{
+		config:         config,
+		accountRepo:    repo,
+		validate:       validate,
+		passwordEngine: passwordEngine,
+		captcha:        captcha,
+		tokens:         tokens,
+	}
+}
+
+type service struct {
+	config         *appconf.Config
+	accountRepo    repository.Repository
+	validate       *xvalidator.Validator
+	passwordEngine *password.Engine
+	captcha        *captcha.Captcha
+	tokens         AuthService.Service
+}
+
+func (s service) Captcha(ctx context.Context) (*domain.CaptchaResponse, *exception.Exception) {
+	key, img, err := s.captcha.Generate()
+	if err != nil {
+		return nil, exception.Internal("failed to generate captcha", err)
+	}
+	return &domain.CaptchaResponse{Sensitive: false, Key: key, Img: img}, nil
+}
+
+func (s service) Login(ctx context.Context, req *domain.LoginRequest) (*authDomain.TokenResponse, *exception.Exception) {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+	if !s.captcha.Verify(req.Key, req.Captcha) {
+		return nil, exception.InvalidArgument("invalid captcha")
+	}
+
+	account, exc := s.authenticate(ctx, req.Email, req.Password)
+	if exc != nil {
+		return nil, exc
+	}
+	if !account.IsVerified() {
+		return nil, exception.PermissionDenied("Account still not verified")
+	}
+	if account.IsPasswordExpired() {
+		return nil, exception.PermissionDenied("password expired, please change it with PUT /account/password/expired")
+	}
+
+	return s.tokens.Issue(ctx, account, req.UserAgent, req.IPAddress)
+}
+
+// ChangeExpiredPassword replaces an expired password. The account cannot get
+// a token until then, so the old password and a captcha stand in for it and
+// failures count towards the same lockout as the login.
+func (s service) ChangeExpiredPassword(ctx context.Context, req *domain.ExpiredPasswordRequest) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+	if !s.captcha.Verify(req.Key, req.Captcha) {
+		return exception.InvalidArgument("invalid captcha")
+	}
+
+	account, exc := s.authenticate(ctx, req.Email, req.OldPassword)
+	if exc != nil {
+		return exc
+	}
+	if !account.IsVerified() {
+		return exception.PermissionDenied("Account still not verified")
+	}
+	if !account.IsPasswordExpired() {
+		return exception.PermissionDenied("password is not expired, please change it with PUT /account/password")
+	}
+
+	if exc := s.applyPassword(ctx, account, req.NewPassword); exc != nil {
+		return exc
+	}
+	if err := s.accountRepo.UpdatePassword(ctx, account); err != nil {
+		return exception.Internal("failed to update password", err)
+	}
+	// whoever knew the old password keeps no session with it
+	return s.tokens.EndSessions(ctx, account.ID, authDomain.RevokedReasonPasswordChanged)
+}
+
+// authenticate returns the account of email when passwd matches. Failed
+// attempts are counted per email and lock it at the MaxInvalid of the policy.
+func (s service) authenticate(ctx context.Context, email, passwd string) (*domain.Account, *exception.Exception) {
+	lockoutKey := domain.NormalizeEmail(email)
+	locked, err := s.passwordEngine.IsLocked(ctx, lockoutKey)
+	if err != nil {
+		return nil, exception.Internal("failed to check password lockout", err)
+	}
+	if locked {
+		return nil, exception.PermissionDenied("account is locked, too many invalid password attempts")
+	}
+
+	account, err := s.accountRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, exception.Internal("failed to find account", err)
+	}
+	if account == nil || !password.CheckPassword(account.Password, passwd) {
+		_, locked, err := s.passwordEngine.RegisterFailure(ctx, lockoutKey)
+		if err != nil {
+			return nil, exception.Internal("failed to record invalid password attempt", err)
+		}
+		if locked {
+			return nil, exception.PermissionDenied("account is locked, too many invalid password attempts")
+		}
+		return nil, exception.Unauthenticated("invalid email or password")
+	}
+	if err := s.passwordEngine.ResetFailures(ctx, lockoutKey); err != nil {
+		logrus.Warnln("failed to reset invalid password attempts", err.Error())
+	}
+	return account, nil
+}
+
+func (s service) FindProfile(ctx context.Context, id int64) (*domain.Account, *exception.Exception) {
+	account, err := s.accountRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, exception.Internal("failed to find account", err)
+	}
+	if account == nil {
+		return nil, exception.NotFound("account not found")
+	}
+	return account, nil
+}
+
+func (s service) ChangePassword(ctx context.Context, id int64, req *domain.ChangePasswordRequest) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+
+	account, err := s.accountRepo.FindByID(ctx, id)
+	if err != nil {
+		return exception.Internal("failed to find account", err)
+	}
+	if account == nil {
+		return exception.NotFound("account not found")
+	}
+	if !password.CheckPassword(account.Password, req.OldPassword) {
+		return exception.Unauthenticated("Password Unmatched")
+	}
+
+	if exc := s.applyPassword(ctx, account, req.NewPassword); exc != nil {
+		return exc
+	}
+	if err := s.accountRepo.UpdatePassword(ctx, account); err != nil {
+		return exception.Internal("failed to update password", err)
+	}
+	// whoever knew the old password keeps no session with it
+	return s.tokens.EndSessions(ctx, account.ID, authDomain.RevokedReasonPasswordChanged)
+}
+
+// applyPassword checks candidate against the password policy and sets the
+// hash and expiry on account.
+func (s service) applyPassword(ctx context.Context, account *domain.Account, candidate string) *exception.Exception {
+	policy, err := s.passwordEngine.Policy(ctx)
+	if err != nil {
+		return exception.Internal("failed to load password policy", err)
+	}
+	history, err := s.accountRepo.FindPasswordHistory(ctx, account.ID, policy.History)
+	if err != nil {
+		return exception.Internal("failed to find password history", err)
+	}
+
+	if err := s.passwordEngine.Validate(ctx, candidate, history); err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			return exception.InvalidArgument(policyErr.Violations)
+		}
+		return exception.Internal("failed to validate password", err)
+	}
+
+	now := time.Now()
+	account.Password = password.HashPassword(candidate)
+	account.PasswordChangedAt = &now
+	account.PasswordExpiredAt = policy.ExpiresAt(now)
+	return nil
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	SessionTableName      = "auth_sessions"
+	RefreshTokenTableName = "auth_refresh_tokens"
+
+	RevokedReasonLogout          = "logout"
+	RevokedReasonReuse           = "refresh_token_reuse"
+	RevokedReasonAdmin           = "admin_revoked"
+	RevokedReasonPasswordChanged = "password_changed"
+)
+
+// Session is one login of an account. Every refresh token issued for it
+// belongs to the session, revoking the session invalidates all of them.
+type Session struct {
+	ID            int64      `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	AccountID     int64      `gorm:"index;not null" json:"account_id"`
+	UserAgent     string     `gorm:"size:255" json:"user_agent"`
+	IPAddress     string     `gorm:"size:45" json:"ip_address"`
+	ExpiredAt     time.Time  `json:"expired_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `gorm:"size:50" json:"revoked_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (model *Session) TableName() string {
+	return os.Getenv("DB_PREFIX") + SessionTableName
+}
+
+// IsActive reports whether the session can still be refreshed.
+func (model *Session) IsActive(now time.Time) bool {
+	return model.RevokedAt == nil && model.ExpiredAt.After(now)
+}
+
+// RefreshToken is stored as a SHA-256 hash. It can be exchanged once, the
+// exchange marks it used and links the token that replaced it.
+type RefreshToken struct {
+	ID           int64      `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	SessionID    int64      `gorm:"index;not null" json:"session_id"`
+	Session      *Session   `gorm:"foreignKey:SessionID" json:"-"`
+	TokenHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiredAt    time.Time  `json:"expired_at"`
+	UsedAt       *time.Time `json:"used_at"`
+	ReplacedByID *int64     `json:"replaced_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (model *RefreshToken) TableName() string {
+	return os.Getenv("DB_PREFIX") + RefreshTokenTableName
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" name:"refresh_token"`
+}
+
+// TokenResponse is returned by login and refresh.
+type TokenResponse struct {
+	JWT              string `json:"jwt"`
+	ExpiresIn        int    `json:"expires_in"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
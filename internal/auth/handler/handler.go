@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"boiler-plate/internal/auth/domain"
+	AuthService "boiler-plate/internal/auth/service"
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+	"strconv"
+)
+
+type HTTPHandler struct {
+	App         *handler.BaseHTTPHandler
+	AuthService AuthService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, authService AuthService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:         handler,
+		AuthService: authService,
+	}
+}
+
+func (h HTTPHandler) Refresh(ctx *app.Context) *server.ResponseInterface {
+	var request domain.RefreshRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.AuthService.Refresh(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Logout(ctx *app.Context) *server.ResponseInterface {
+	id, ok := ctx.SubjectID()
+	if !ok {
+		return h.App.AsException(ctx, exception.Unauthenticated("invalid token subject"))
+	}
+
+	if exc := h.AuthService.Logout(ctx, id, ctx.SessionID(), ctx.TokenID(), ctx.TokenExpiresAt()); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
+
+// RevokeAccount revokes every token of the account in the path.
+func (h HTTPHandler) RevokeAccount(ctx *app.Context) *server.ResponseInterface {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument("id must be a number"))
+	}
+
+	if exc := h.AuthService.RevokeAccount(ctx, id); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
+
+// JWKS serves the public signing keys as a bare JSON Web Key Set, the format
+// JWT libraries expect, instead of the usual response envelope.
+func (h HTTPHandler) JWKS(ctx *app.Context) *server.ResponseInterface {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	return h.App.AsJsonInterface(ctx, http.StatusOK, h.App.JWTKeys.JWKS())
+}
+
+// ResponseSigningKey serves the public key partners verify signed responses
+// with, see signature.VerifyHTTPResponse.
+func (h HTTPHandler) ResponseSigningKey(ctx *app.Context) *server.ResponseInterface {
+	signer := h.App.ResponseSigner
+	if signer == nil {
+		return h.App.AsException(ctx, exception.NotFound("response signing is not configured"))
+	}
+	publicKey, err := signer.PublicKeyPEM()
+	if err != nil {
+		return h.App.AsException(ctx, exception.Internal("failed to encode public key", err))
+	}
+	ctx.Header("Cache-Control", "public, max-age=300")
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, map[string]string{
+		"key_id":     signer.KeyID(),
+		"public_key": publicKey,
+	})
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"boiler-plate/internal/auth/domain"
+	"context"
+	"errors"
+)
+
+// ErrTokenUsed is returned by Rotate when the refresh token was exchanged
+// by someone else first.
+var ErrTokenUsed = errors.New("refresh token already used")
+
+type Repository interface {
+	CreateSession(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error
+	FindSession(ctx context.Context, id int64) (*domain.Session, error)
+	FindRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	Rotate(ctx context.Context, used *domain.RefreshToken, next *domain.RefreshToken) error
+	RevokeSession(ctx context.Context, id int64, reason string) error
+	RevokeAccountSessions(ctx context.Context, accountID int64, reason string) error
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"boiler-plate/internal/auth/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db   *gorm.DB
+	base *baseModel.SQLClientRepository
+}
+
+func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
+	return &Repo{db: db, base: base}
+}
+
+// CreateSession stores a new session with its first refresh token.
+func (r Repo) CreateSession(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (r Repo) FindSession(ctx context.Context, id int64) (*domain.Session, error) {
+	var (
+		models *domain.Session
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ?", id).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var (
+		models *domain.RefreshToken
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Preload("Session").
+		Where("token_hash = ?", tokenHash).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+// Rotate marks used as exchanged for next in one transaction. The update only
+// matches an unused token, so of two concurrent exchanges one gets
+// ErrTokenUsed.
+func (r Repo) Rotate(ctx context.Context, used *domain.RefreshToken, next *domain.RefreshToken) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		next.SessionID = used.SessionID
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", used.ID).
+			Updates(map[string]interface{}{"used_at": now, "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenUsed
+		}
+		return tx.Model(&domain.Session{}).
+			Where("id = ?", used.SessionID).
+			Update("last_used_at", now).Error
+	})
+	if errors.Is(err, ErrTokenUsed) {
+		return err
+	}
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// RevokeSession revokes the session, an already revoked session keeps its
+// first reason.
+func (r Repo) RevokeSession(ctx context.Context, id int64, reason string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// RevokeAccountSessions revokes every active session of an account.
+func (r Repo) RevokeAccountSessions(ctx context.Context, accountID int64, reason string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("account_id = ? AND revoked_at IS NULL", accountID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	accountDomain "boiler-plate/internal/account/domain"
+	"boiler-plate/internal/auth/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+	"time"
+)
+
+type Service interface {
+	// Issue starts a session for account and returns its first token pair.
+	Issue(ctx context.Context, account *accountDomain.Account, userAgent, ipAddress string) (
+		*domain.TokenResponse, *exception.Exception,
+	)
+	// Refresh exchanges a refresh token for a new pair. Presenting a token
+	// that was already exchanged revokes the whole session. The account has
+	// to pass the same checks as the login.
+	Refresh(ctx context.Context, req *domain.RefreshRequest) (*domain.TokenResponse, *exception.Exception)
+	// Logout revokes the session and the access token presented with it.
+	Logout(ctx context.Context, accountID, sessionID int64, tokenID string, tokenExpiresAt time.Time) *exception.Exception
+	// RevokeAccount revokes every session and access token of an account,
+	// used when offboarding or when an account is compromised.
+	RevokeAccount(ctx context.Context, accountID int64) *exception.Exception
+	// EndSessions revokes every session and access token of accountID for
+	// reason, the account has to log in again.
+	EndSessions(ctx context.Context, accountID int64, reason string) *exception.Exception
+}
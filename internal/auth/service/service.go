@@ -0,0 +1,252 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	accountDomain "boiler-plate/internal/account/domain"
+	accountRepository "boiler-plate/internal/account/repository"
+	"boiler-plate/internal/auth/domain"
+	"boiler-plate/internal/auth/repository"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/revocation"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewService creates new auth service
+func NewService(
+	config *appconf.Config, repo repository.Repository, accountRepo accountRepository.Repository,
+	validate *xvalidator.Validator, keys *jwt.KeySet, revocations revocation.Store, passwordEngine *password.Engine,
+) Service {
+	return &service{
+		config:         config,
+		authRepo:       repo,
+		accountRepo:    accountRepo,
+		validate:       validate,
+		keys:           keys,
+		revocations:    revocations,
+		passwordEngine: passwordEngine,
+	}
+}
+
+type service struct {
+	config         *appconf.Config
+	authRepo       repository.Repository
+	accountRepo    accountRepository.Repository
+	validate       *xvalidator.Validator
+	keys           *jwt.KeySet
+	revocations    revocation.Store
+	passwordEngine *password.Engine
+}
+
+func (s service) Issue(ctx context.Context, account *accountDomain.Account, userAgent, ipAddress string) (
+	*domain.TokenResponse, *exception.Exception,
+) {
+	now := time.Now()
+	refreshToken, token, err := s.newRefreshToken(now, now.Add(s.config.AuthConfig.JwtSessionTTL))
+	if err != nil {
+		return nil, exception.Internal("failed to generate refresh token", err)
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	session := &domain.Session{
+		AccountID:  account.ID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		ExpiredAt:  now.Add(s.config.AuthConfig.JwtSessionTTL),
+		LastUsedAt: now,
+	}
+	if err := s.authRepo.CreateSession(ctx, session, token); err != nil {
+		return nil, exception.Internal("failed to create session", err)
+	}
+	return s.tokenResponse(ctx, account, session.ID, refreshToken, token.ExpiredAt)
+}
+
+func (s service) Refresh(ctx context.Context, req *domain.RefreshRequest) (*domain.TokenResponse, *exception.Exception) {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+
+	token, err := s.authRepo.FindRefreshToken(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, exception.Internal("failed to find refresh token", err)
+	}
+	if token == nil || token.Session == nil {
+		return nil, exception.Unauthenticated("invalid refresh token")
+	}
+	session := token.Session
+	if token.UsedAt != nil {
+		return nil, s.revokeReused(ctx, session)
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) || token.ExpiredAt.Before(now) {
+		return nil, exception.Unauthenticated("refresh token expired, please login again")
+	}
+
+	account, err := s.accountRepo.FindByID(ctx, session.AccountID)
+	if err != nil {
+		return nil, exception.Internal("failed to find account", err)
+	}
+	if account == nil {
+		return nil, exception.Unauthenticated("account not found")
+	}
+	// the session outlives the access token, what blocks a login has to
+	// block the refresh as well
+	if !account.IsVerified() {
+		return nil, exception.PermissionDenied("Account still not verified")
+	}
+	if account.IsPasswordExpired() {
+		return nil, exception.PermissionDenied("password expired, please change it with PUT /account/password/expired")
+	}
+	locked, err := s.passwordEngine.IsLocked(ctx, accountDomain.NormalizeEmail(account.Email))
+	if err != nil {
+		return nil, exception.Internal("failed to check password lockout", err)
+	}
+	if locked {
+		return nil, exception.PermissionDenied("account is locked, too many invalid password attempts")
+	}
+
+	refreshToken, next, err := s.newRefreshToken(now, session.ExpiredAt)
+	if err != nil {
+		return nil, exception.Internal("failed to generate refresh token", err)
+	}
+	if err := s.authRepo.Rotate(ctx, token, next); err != nil {
+		if errors.Is(err, repository.ErrTokenUsed) {
+			return nil, s.revokeReused(ctx, session)
+		}
+		return nil, exception.Internal("failed to rotate refresh token", err)
+	}
+	return s.tokenResponse(ctx, account, session.ID, refreshToken, next.ExpiredAt)
+}
+
+func (s service) Logout(
+	ctx context.Context, accountID, sessionID int64, tokenID string, tokenExpiresAt time.Time,
+) *exception.Exception {
+	if sessionID == 0 {
+		return exception.InvalidArgument("access token is not bound to a session")
+	}
+	session, err := s.authRepo.FindSession(ctx, sessionID)
+	if err != nil {
+		return exception.Internal("failed to find session", err)
+	}
+	if session == nil || session.AccountID != accountID {
+		return exception.NotFound("session not found")
+	}
+	if err := s.authRepo.RevokeSession(ctx, session.ID, domain.RevokedReasonLogout); err != nil {
+		return exception.Internal("failed to revoke session", err)
+	}
+	// the access token itself stays valid until exp unless revoked
+	if tokenID != "" {
+		if err := s.revocations.RevokeToken(ctx, tokenID, accountID, tokenExpiresAt); err != nil {
+			return exception.Internal("failed to revoke access token", err)
+		}
+	}
+	return nil
+}
+
+func (s service) RevokeAccount(ctx context.Context, accountID int64) *exception.Exception {
+	account, err := s.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return exception.Internal("failed to find account", err)
+	}
+	if account == nil {
+		return exception.NotFound("account not found")
+	}
+	return s.EndSessions(ctx, accountID, domain.RevokedReasonAdmin)
+}
+
+func (s service) EndSessions(ctx context.Context, accountID int64, reason string) *exception.Exception {
+	if err := s.authRepo.RevokeAccountSessions(ctx, accountID, reason); err != nil {
+		return exception.Internal("failed to revoke sessions", err)
+	}
+	if err := s.revocations.RevokeSubject(ctx, accountID, s.config.AuthConfig.JwtAccessTokenTTL); err != nil {
+		return exception.Internal("failed to revoke access tokens", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"account_id": accountID,
+		"reason":     reason,
+	}).Warnln("all tokens of account revoked")
+	return nil
+}
+
+// revokeReused ends a session whose refresh token was presented twice, one
+// of the two holders is not the owner.
+func (s service) revokeReused(ctx context.Context, session *domain.Session) *exception.Exception {
+	logrus.WithFields(logrus.Fields{
+		"session_id": session.ID,
+		"account_id": session.AccountID,
+	}).Warnln("refresh token reuse detected, revoking session")
+	if err := s.authRepo.RevokeSession(ctx, session.ID, domain.RevokedReasonReuse); err != nil {
+		return exception.Internal("failed to revoke session", err)
+	}
+	return exception.Unauthenticated("refresh token reuse detected, please login again")
+}
+
+// newRefreshToken returns a random token and its stored form. The token
+// expires after JwtRefreshTokenTTL but never after the session.
+func (s service) newRefreshToken(now, sessionExpiredAt time.Time) (string, *domain.RefreshToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	expiredAt := now.Add(s.config.AuthConfig.JwtRefreshTokenTTL)
+	if expiredAt.After(sessionExpiredAt) {
+		expiredAt = sessionExpiredAt
+	}
+	return value, &domain.RefreshToken{TokenHash: hashToken(value), ExpiredAt: expiredAt}, nil
+}
+
+// tokenResponse signs an access token carrying the role and permissions of
+// account, bound to sessionID.
+func (s service) tokenResponse(
+	ctx context.Context, account *accountDomain.Account, sessionID int64, refreshToken string, refreshExpiredAt time.Time,
+) (*domain.TokenResponse, *exception.Exception) {
+	claims := &jwt.Claims{
+		Sub:        account.ID,
+		Name:       account.Fullname,
+		Email:      account.Email,
+		RoleId:     account.RoleID,
+		Roles:      []string{},
+		Permission: []string{},
+		SessionID:  sessionID,
+	}
+	if account.Role != nil {
+		claims.Roles = append(claims.Roles, account.Role.Name)
+		permissions, err := s.accountRepo.FindPermissions(ctx, account.RoleID)
+		if err != nil {
+			return nil, exception.Internal("failed to find permissions", err)
+		}
+		claims.Permission = permissions
+	}
+
+	ttl := s.config.AuthConfig.JwtAccessTokenTTL
+	accessToken, err := jwt.AuthJWT(claims, ttl, s.keys)
+	if err != nil {
+		return nil, exception.Internal("failed to sign token", err)
+	}
+	return &domain.TokenResponse{
+		JWT:              accessToken,
+		ExpiresIn:        int(ttl.Seconds()),
+		TokenType:        "bearer",
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(time.Until(refreshExpiredAt).Seconds()),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
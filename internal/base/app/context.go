@@ -2,7 +2,10 @@ package app
 
 import (
 	"boiler-plate/app/appconf"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -32,3 +35,78 @@ func NewContext(c *gin.Context, conf *appconf.Config) *Context {
 
 	return ctx
 }
+
+// Subject returns the sub claim stored by UserRunAction, empty for guests.
+func (c *Context) Subject() string {
+	sub, _ := c.Get("sub")
+	return formatSubject(sub)
+}
+
+// formatSubject renders a decoded sub claim, JSON numbers become integers.
+func formatSubject(sub interface{}) string {
+	if sub == nil {
+		return ""
+	}
+	if number, ok := sub.(float64); ok {
+		return strconv.FormatInt(int64(number), 10)
+	}
+	return fmt.Sprint(sub)
+}
+
+// SubjectID returns the sub claim as an account id.
+func (c *Context) SubjectID() (int64, bool) {
+	id, err := strconv.ParseInt(c.Subject(), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// SessionID returns the sid claim, zero when the token has none.
+func (c *Context) SessionID() int64 {
+	sid, _ := c.Get("sid")
+	number, _ := sid.(float64)
+	return int64(number)
+}
+
+// TokenID returns the jti claim of the access token.
+func (c *Context) TokenID() string {
+	jti, _ := c.Get("jti")
+	id, _ := jti.(string)
+	return id
+}
+
+// TokenExpiresAt returns the exp claim of the access token.
+func (c *Context) TokenExpiresAt() time.Time {
+	exp, _ := c.Get("exp")
+	number, _ := exp.(float64)
+	return time.Unix(int64(number), 0)
+}
+
+// HasRole reports whether the roles claim contains role.
+func (c *Context) HasRole(role string) bool {
+	return claimContains(c, "roles", role)
+}
+
+// HasPermission reports whether the permission claim contains permission.
+func (c *Context) HasPermission(permission string) bool {
+	return claimContains(c, "permission", permission)
+}
+
+func claimContains(c *Context, claim, value string) bool {
+	values, _ := c.Get(claim)
+	list, _ := values.([]interface{})
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientID returns the client_id claim stored by ClientRunAction.
+func (c *Context) ClientID() string {
+	clientID, _ := c.Get("client_id")
+	id, _ := clientID.(string)
+	return id
+}
@@ -0,0 +1,41 @@
+package app
+
+import "context"
+
+// Handlers that do not run behind gin, such as the gRPC server, carry the
+// request ID and the token claims on a plain context.Context.
+
+type requestIDKey struct{}
+
+type claimsKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID or, for a
+// gin request, the X-API-Request-ID of the *Context.
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*Context); ok {
+		return c.APIReqID
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func WithClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) map[string]interface{} {
+	claims, _ := ctx.Value(claimsKey{}).(map[string]interface{})
+	return claims
+}
+
+// SubjectFromContext returns the sub claim like Context.Subject.
+func SubjectFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*Context); ok {
+		return c.Subject()
+	}
+	return formatSubject(ClaimsFromContext(ctx)["sub"])
+}
@@ -1,6 +0,0 @@
-package domain
-
-type Status struct {
-	ResponseCode    int    `json:"responseCode"`
-	ResponseMessage string `json:"responseMessage"`
-}
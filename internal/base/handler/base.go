@@ -1,13 +1,24 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
 	"boiler-plate/pkg/httpclient"
+	"boiler-plate/pkg/httputils"
+	authjwt "boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/policy"
+	"boiler-plate/pkg/revocation"
+	"boiler-plate/pkg/signature"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
@@ -20,7 +31,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-type HandlerFn func(ctx *app.Context) *server.Response
+var errTokenRevoked = errors.New("token has been revoked")
+
+const (
+	headerRequestID      = "X-API-Request-ID"
+	headerResponseFormat = "X-Response-Format"
+)
+
+// MessageSuccess is the message of every successful response, the frontend
+// reads it from responseMessage in the legacy format.
+const MessageSuccess = "Successful"
+
 type HandlerFnInterface func(ctx *app.Context) *server.ResponseInterface
 
 type BaseHTTPHandler struct {
@@ -29,6 +50,14 @@ type BaseHTTPHandler struct {
 	AppConfig  *appconf.Config
 	BaseModel  *baseModel.SQLClientRepository
 	HttpClient httpclient.Client
+	// JWTKeys verifies access tokens by their kid.
+	JWTKeys *authjwt.KeySet
+	// Revocations rejects tokens revoked before they expire.
+	Revocations revocation.Store
+	// Signatures verifies the requests of SignedRunAction.
+	Signatures *signature.Verifier
+	// ResponseSigner signs the responses of routes wrapped in SignResponse.
+	ResponseSigner *signature.ResponseSigner
 }
 
 func NewBaseHTTPHandler(
@@ -36,433 +65,471 @@ func NewBaseHTTPHandler(
 	appConfig *appconf.Config,
 	baseModel *baseModel.SQLClientRepository,
 	httpClient httpclient.Client,
+	jwtKeys *authjwt.KeySet,
+	revocations revocation.Store,
 ) *BaseHTTPHandler {
 	return &BaseHTTPHandler{
-		DB:         db,
-		AppConfig:  appConfig,
-		BaseModel:  baseModel,
-		HttpClient: httpClient,
+		DB:          db,
+		AppConfig:   appConfig,
+		BaseModel:   baseModel,
+		HttpClient:  httpClient,
+		JWTKeys:     jwtKeys,
+		Revocations: revocations,
 	}
 }
 
 // Handler Basic Method ======================================================================================================
-func (h BaseHTTPHandler) AsErrorMessage(ctx *gin.Context, message string) {
-	ctx.JSON(http.StatusInternalServerError, gin.H{
-		"responseCode":    "500",
-		"responseMessage": message,
-	})
-}
-
-func (h BaseHTTPHandler) AsErrorDefault(ctx *gin.Context, message string) {
-	ctx.JSON(http.StatusInternalServerError, gin.H{
-		"message": message,
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidClientIdError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "400",
-		"responseMessage": "invalid clientid",
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidClientIdAccessTokenError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4010000",
-		"responseMessage": "Invalid Client Key",
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidPrivateKeyError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4010000",
-		"responseMessage": "Invalid Private Key",
-	})
-}
-
-func (h BaseHTTPHandler) AsDatabaseError(ctx *gin.Context) {
-	ctx.JSON(http.StatusInternalServerError, gin.H{
-		"responseCode":    "500",
-		"responseMessage": "Error in database",
-	})
-}
-
-func (h BaseHTTPHandler) AsNotVerfied(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": "Account still not verified",
-	})
-}
-
-func (h BaseHTTPHandler) AsDuplicateEmail(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": "Another account with same email already created",
-	})
-}
-
-func (h BaseHTTPHandler) AsDataNotFound(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotFound, gin.H{
-		"responseCode":    "404",
-		"responseMessage": "Data not Found",
-	})
-}
-
-func (h BaseHTTPHandler) AsJWTExist(ctx *gin.Context, token string) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": "You already login before",
-		"token":           token,
-	})
-}
-
-func (h BaseHTTPHandler) AsPasswordUnmatched(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": "Password Unmatched",
-	})
-}
-
-func (h BaseHTTPHandler) AsHashError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "500",
-		"responseMessage": "Error in hashing",
-	})
-}
-
-func (h BaseHTTPHandler) AsDataUnauthorized(ctx *gin.Context, message string) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": message,
-	})
-}
-
-func (h BaseHTTPHandler) AsEmailNotFound(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "401",
-		"responseMessage": "Can't send email, contact admin for verification",
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidPublicKeyError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4010000",
-		"responseMessage": "Invalid Public Key",
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidSignatureError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4017300",
-		"responseMessage": "Invalid Token (B2B)",
-	})
-}
-
-func (h BaseHTTPHandler) AsRequiredTimeStampError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The timestamp field is required.",
-	})
-}
-
-func (h BaseHTTPHandler) AsInvalidFieldTimeStampError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "Invalid Field Format Timestamp",
-	})
-}
 
-func (h BaseHTTPHandler) AsInvalidLengthTimeStampError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The field timestamp must be a string or array type with a maximum length of '25'.",
-	})
+// DataNotFound returns 404 when data doesn't exist
+func (h BaseHTTPHandler) DataNotFound(ctx *app.Context) *server.ResponseInterface {
+	return h.AsException(ctx, exception.NotFound("Data not found in database."))
 }
 
-func (h BaseHTTPHandler) AsInvalidClientSecretError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4010000",
-		"responseMessage": "Invalid Client Secret",
-	})
+// AsJsonInterface writes data as the body without the envelope, only for
+// bodies defined by a protocol like JWKS or the OAuth2 token response.
+func (b BaseHTTPHandler) AsJsonInterface(ctx *app.Context, status int, data interface{}) *server.ResponseInterface {
+	return &server.ResponseInterface{
+		Status: status,
+		Data:   data,
+		Raw:    true,
+	}
 }
 
-func (h BaseHTTPHandler) AsInvalidHttpMethodError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4010000",
-		"responseMessage": "http methods is invalid",
-	})
+// AsData to response data in the envelope
+func (b BaseHTTPHandler) AsData(ctx *app.Context, status int, message string, data interface{}) *server.ResponseInterface {
+	return &server.ResponseInterface{
+		Status:  status,
+		Message: message,
+		Data:    data,
+	}
 }
 
-func (h BaseHTTPHandler) AsInvalidJsonFormat(ctx *gin.Context, msg string) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "400",
-		"responseMessage": msg,
-	})
+// AsPage to response a list in the envelope with its pagination as meta
+func (b BaseHTTPHandler) AsPage(
+	ctx *app.Context, status int, message string, data interface{}, paginate *baseModel.Paginate,
+) *server.ResponseInterface {
+	resp := b.AsData(ctx, status, message, data)
+	resp.Meta = &httputils.Meta{
+		Page:       paginate.Page,
+		Limit:      paginate.Limit,
+		TotalRows:  paginate.TotalRows,
+		TotalPages: paginate.TotalPages,
+	}
+	return resp
 }
 
-func (h BaseHTTPHandler) AsRequiredClientSecretError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The clientSecret field is required.",
-	})
+// AsException returns an exception from a service, the run action renders
+// it with errorBody.
+func (b BaseHTTPHandler) AsException(ctx *app.Context, exc *exception.Exception) *server.ResponseInterface {
+	return &server.ResponseInterface{Status: exc.HTTPStatus(), Err: exc}
 }
 
-func (h BaseHTTPHandler) AsRequiredClientIdError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The param ID is required.",
-	})
+// AsError returns any error, see exception.From.
+func (b BaseHTTPHandler) AsError(ctx *app.Context, err error) *server.ResponseInterface {
+	return b.AsException(ctx, exception.From(err))
 }
 
-func (h BaseHTTPHandler) AsRequiredGrantTypeError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4007302",
-		"responseMessage": "Bad Request. The grantType field is required.",
-	})
+// renderError answers the request with exc, used before a handler ran.
+func (b BaseHTTPHandler) renderError(ctx *app.Context, exc *exception.Exception) {
+	ctx.Header(headerRequestID, ctx.APIReqID)
+	ctx.JSON(exc.HTTPStatus(), b.errorBody(ctx, exc))
 }
 
-func (h BaseHTTPHandler) AsRequiredGrantTypeClientCredentialsError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4007300",
-		"responseMessage": "grant_type must be set to client_credentials",
-	})
+// AbortWithException stops the handler chain of a middleware and answers
+// with exc in the same body as the run actions.
+func (b BaseHTTPHandler) AbortWithException(c *gin.Context, exc *exception.Exception) {
+	b.renderError(app.NewContext(c, b.AppConfig), exc)
+	c.Abort()
 }
 
-func (h BaseHTTPHandler) AsRequiredSignatureError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The signature field is required.",
-	})
-}
+// errorBody logs exc with the request ID and builds its response body. The
+// cause and stack trace are only shown in debug mode. SNAP exceptions always
+// use the legacy body.
+func (b BaseHTTPHandler) errorBody(ctx *app.Context, exc *exception.Exception) interface{} {
+	if exc.HTTPStatus() >= http.StatusInternalServerError {
+		logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , code: %s , message: %v , error: %v\n%s",
+			ctx.APIReqID, exc.BusinessCode(), exc.Message, exc.Cause, exc.Stack))
+	} else {
+		logrus.Warnln(fmt.Sprintf("REQUEST ID: %s , code: %s , message: %v , error: %v",
+			ctx.APIReqID, exc.BusinessCode(), exc.Message, exc.Cause))
+	}
 
-func (h BaseHTTPHandler) AsRequiredPrivateKeyError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The privateKey field is required.",
-	})
+	env := httputils.GenErrorEnvelope(exc, ctx.APIReqID)
+	if b.AppConfig.IsDebug() {
+		env.StackTrace = exc.Stack
+	} else {
+		env.DetailError = ""
+	}
+	if exc.IsSNAP() {
+		return b.snapBody(ctx, env)
+	}
+	return b.formatBody(ctx, env)
 }
 
-func (h BaseHTTPHandler) AsRequiredContentTypeError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "Content Type application/json is required.",
+// envelopeBody builds the envelope of a successful handler result.
+func (b BaseHTTPHandler) envelopeBody(ctx *app.Context, resp *server.ResponseInterface) interface{} {
+	message := resp.Message
+	if message == "" {
+		message = MessageSuccess
+	}
+	return b.formatBody(ctx, &httputils.Envelope{
+		Status:       resp.Status,
+		ResponseCode: exception.StatusResponseCode(resp.Status),
+		Message:      message,
+		Data:         resp.Data,
+		RequestID:    ctx.APIReqID,
+		Meta:         resp.Meta,
 	})
 }
 
-func (h BaseHTTPHandler) AsInvalidTokenError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4010001",
-		"responseMessage": "Access Token Invalid",
-	})
+// formatBody returns env in the format asked by the X-Response-Format
+// header, RESPONSE_FORMAT otherwise.
+func (b BaseHTTPHandler) formatBody(ctx *app.Context, env *httputils.Envelope) interface{} {
+	format := ctx.GetHeader(headerResponseFormat)
+	if format != httputils.FormatEnvelope && format != httputils.FormatLegacy {
+		format = b.AppConfig.AppEnvConfig.ResponseFormat
+	}
+	if format == httputils.FormatLegacy {
+		return b.legacyBody(ctx, env)
+	}
+	return env
 }
 
-func (h BaseHTTPHandler) AsRequiredBearer(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnauthorized, gin.H{
-		"responseCode":    "4000002",
-		"responseMessage": "Bearer authorization is required",
-	})
+func (b BaseHTTPHandler) legacyBody(ctx *app.Context, env *httputils.Envelope) interface{} {
+	body, err := env.Legacy()
+	if err != nil {
+		logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: failed to encode legacy response, %v", ctx.APIReqID, err))
+		return env
+	}
+	return body
 }
 
-func (h BaseHTTPHandler) AsRequiredHttpMethodError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The HttpMethod field is required.",
-	})
+func (b BaseHTTPHandler) snapBody(ctx *app.Context, env *httputils.Envelope) interface{} {
+	body, err := env.SNAP()
+	if err != nil {
+		logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: failed to encode SNAP response, %v", ctx.APIReqID, err))
+		return env
+	}
+	return body
 }
 
-func (h BaseHTTPHandler) AsRequiredEndpoinUrlError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The EndpointUrl field is required.",
-	})
+// recoverPanic answers a panicking handler with 500.
+func (b BaseHTTPHandler) recoverPanic(ctx *app.Context) {
+	if err0 := recover(); err0 != nil {
+		b.renderError(ctx, exception.Internal(
+			"Request is halted unexpectedly, please contact the administrator.", fmt.Errorf("panic: %v", err0),
+		))
+	}
 }
 
-func (h BaseHTTPHandler) AsRequiredAccessTokenError(ctx *gin.Context) {
-	ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "The AccessToken field is required.",
-	})
-}
-func (h BaseHTTPHandler) AsRequiredBodyError(ctx *gin.Context) {
-	ctx.JSON(http.StatusBadRequest, gin.H{
-		"responseCode":    "4000000",
-		"responseMessage": "A non-empty request body is required.",
-	})
+func (b BaseHTTPHandler) UserAuthentication(c *gin.Context) (*app.Context, error) {
+	return app.NewContext(c, b.AppConfig), nil
 }
 
-// Data Not Found return AsJsonInterface 404 when data doesn't exist
-func (h BaseHTTPHandler) DataNotFound(ctx *app.Context) *server.ResponseInterface {
-	type Response struct {
-		StatusCode int         `json:"responseCode"`
-		Message    interface{} `json:"responseMessage"`
+// ParseAccessToken validates a user access token, rejects revoked ones and
+// returns its claims. It is shared by UserRunAction and the gRPC auth
+// interceptor.
+func (b BaseHTTPHandler) ParseAccessToken(ctx context.Context, authToken string) (jwt.MapClaims, error) {
+	claims, err := b.parseToken(ctx, authToken)
+	if err != nil {
+		return nil, err
 	}
-	resp := Response{
-		StatusCode: http.StatusNotFound,
-		Message:    "Data not found in database.",
+	if _, ok := claims["client_id"]; ok {
+		return nil, errors.New("client token used as user token")
 	}
-	return h.AsJsonInterface(ctx, http.StatusNotFound, resp)
-
+	return claims, nil
 }
 
-// DataReadError return AsJsonInterface error if persist a problem in declared condition
-func (h BaseHTTPHandler) DataReadError(ctx *app.Context, code int, description string) *server.ResponseInterface {
-	type Response struct {
-		StatusCode int         `json:"responseCode"`
-		Message    interface{} `json:"responseMessage"`
+// ParseClientToken validates a client_credentials token and returns its
+// claims.
+func (b BaseHTTPHandler) ParseClientToken(ctx context.Context, authToken string) (jwt.MapClaims, error) {
+	claims, err := b.parseToken(ctx, authToken)
+	if err != nil {
+		return nil, err
 	}
-	resp := Response{
-		StatusCode: code,
-		Message:    description,
+	if clientID, _ := claims["client_id"].(string); clientID == "" {
+		return nil, errors.New("user token used as client token")
 	}
-	return h.AsJsonInterface(ctx, code, resp)
+	return claims, nil
 }
 
-// AsJson to response custom message: 200, 201 with message (Mobile use 500 error)
-func (b BaseHTTPHandler) AsJson(ctx *app.Context, status int, message string, data interface{}) *server.Response {
-
-	return &server.Response{
-		Status:       status,
-		Message:      message,
-		Data:         data,
-		ResponseType: server.DefaultResponseType,
+func (b BaseHTTPHandler) parseToken(ctx context.Context, authToken string) (jwt.MapClaims, error) {
+	claims, err := b.JWTKeys.Parse(authToken)
+	if err != nil {
+		return nil, err
 	}
-}
-
-func (b BaseHTTPHandler) AsJsonInterface(ctx *app.Context, status int, data interface{}) *server.ResponseInterface {
 
-	return &server.ResponseInterface{
-		Status: status,
-		Data:   data,
+	tokenID, _ := claims["jti"].(string)
+	subjectID, _ := claims["sub"].(float64)
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return nil, fmt.Errorf("invalid token")
 	}
-}
-
-// ThrowExceptionJson for some exception not handle in Yii2 framework
-func (b BaseHTTPHandler) ThrowExceptionJson(ctx *app.Context, status, code int, name, message string) *server.Response {
-	return &server.Response{
-		Status:  status,
-		Message: "",
-		Log:     nil,
+	revoked, err := b.Revocations.IsRevoked(ctx, tokenID, int64(subjectID), issuedAt.Time)
+	if err != nil {
+		return nil, err
 	}
+	if revoked {
+		return nil, errTokenRevoked
+	}
+	return claims, nil
 }
 
-func (b BaseHTTPHandler) UserAuthentication(c *gin.Context) (*app.Context, error) {
-	return app.NewContext(c, b.AppConfig), nil
-}
-
-func (b BaseHTTPHandler) UserRunAction(handler HandlerFnInterface) gin.HandlerFunc {
+// UserRunAction runs handler for a caller with a valid access token. When
+// policies are given the token must also pass every one of them, otherwise
+// the request is answered with 403 before the handler runs.
+func (b BaseHTTPHandler) UserRunAction(handler HandlerFnInterface, policies ...policy.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		ctx, err := b.UserAuthentication(c)
 		if err != nil {
-			logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized", ctx.APIReqID))
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  http.StatusUnauthorized,
-				"message": "Unauthorized",
-				"data":    err.Error(),
-			})
+			exc := exception.Unauthenticated("Unauthorized")
+			exc.Cause = err
+			b.renderError(ctx, exc)
 			return
 		}
 
-		defer func() {
-			if err0 := recover(); err0 != nil {
-				logrus.Errorln(err0)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"status":  http.StatusInternalServerError,
-					"message": "Request is halted unexpectedly, please contact the administrator.",
-					"data":    nil,
-				})
-			}
-		}()
+		defer b.recoverPanic(ctx)
 
 		// Extract bearer token from request header
 		tokenString := strings.Split(ctx.GetHeader("Authorization"), " ")[1:]
 		if len(tokenString) == 0 {
-			logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized", ctx.APIReqID))
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  http.StatusUnauthorized,
-				"message": "request does not contain an access token",
-			})
+			b.renderError(ctx, exception.Unauthenticated("request does not contain an access token"))
 			return
 		}
 		authToken := tokenString[0]
 
 		// Validate token
-		token, err := jwt.ParseWithClaims(authToken, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return b.AppConfig.AuthConfig.JwtSecretAccessToken, nil
-		})
-		if err != nil || !token.Valid {
-			logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized", ctx.APIReqID))
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  http.StatusUnauthorized,
-				"message": "unauthorized",
-			})
+		claims, err := b.ParseAccessToken(ctx, authToken)
+		if err != nil {
+			exc := exception.Unauthenticated("unauthorized")
+			exc.Cause = err
+			b.renderError(ctx, exc)
 			return
 		}
 
 		// Get user data from token
-		claims := token.Claims.(jwt.MapClaims)
 		ctx.Set("sub", claims["sub"])
 		ctx.Set("role_id", claims["role_id"])
 		ctx.Set("email", claims["email"])
+		ctx.Set("roles", claims["roles"])
+		ctx.Set("permission", claims["permission"])
+		ctx.Set("sid", claims["sid"])
+		ctx.Set("jti", claims["jti"])
+		ctx.Set("exp", claims["exp"])
+
+		if err := policy.EvaluateAll(policy.SubjectFromClaims(claims), policies); err != nil {
+			exc := exception.PermissionDenied(err.Error())
+			exc.Cause = fmt.Errorf("sub %v: %w", claims["sub"], err)
+			b.renderError(ctx, exc)
+			return
+		}
 
 		// Execute handler
-		resp := handler(ctx)
-		httpStatus := resp.Status
+		b.writeResponse(ctx, start, handler(ctx))
+	}
+}
 
-		if resp.Data == nil {
-			c.Status(httpStatus)
+// ClientRunAction runs handler for a machine client holding a
+// client_credentials token. The token must carry every one of scopes.
+func (b BaseHTTPHandler) ClientRunAction(handler HandlerFnInterface, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := app.NewContext(c, b.AppConfig)
+
+		defer b.recoverPanic(ctx)
+
+		tokenString := strings.Split(ctx.GetHeader("Authorization"), " ")
+		if len(tokenString) != 2 || !strings.EqualFold(tokenString[0], "Bearer") {
+			b.renderError(ctx, exception.RequiredBearer())
 			return
 		}
-		end := time.Now().Sub(start)
-		logrus.Infoln(fmt.Sprintf("REQUEST ID: %s , LATENCY: %vms", ctx.APIReqID, end.Milliseconds()))
-		c.JSON(httpStatus, resp.Data)
 
-	}
-}
+		claims, err := b.ParseClientToken(ctx, tokenString[1])
+		if err != nil {
+			exc := exception.InvalidAccessToken()
+			exc.Cause = err
+			b.renderError(ctx, exc)
+			return
+		}
 
-func (b BaseHTTPHandler) GuestAuthentication(c *gin.Context) (*app.Context, error) {
-	return app.NewContext(c, b.AppConfig), nil
+		scope, _ := claims["scope"].(string)
+		granted := strings.Fields(scope)
+		for _, scope := range scopes {
+			if !policy.Contains(granted, scope) {
+				exc := exception.PermissionDenied("requires scope " + scope)
+				exc.Cause = fmt.Errorf("client %v is missing scope %s", claims["client_id"], scope)
+				b.renderError(ctx, exc)
+				return
+			}
+		}
+
+		ctx.Set("client_id", claims["client_id"])
+		ctx.Set("scope", claims["scope"])
+
+		b.writeResponse(ctx, start, handler(ctx))
+	}
 }
 
-func (b BaseHTTPHandler) GuestRunAction(handler HandlerFnInterface) gin.HandlerFunc {
+// SignedRunAction runs handler for a B2B partner whose request is signed
+// with its registered private key, see signature.StringToSign. The client
+// must be granted every one of scopes.
+func (b BaseHTTPHandler) SignedRunAction(handler HandlerFnInterface, scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		ctx, err := b.GuestAuthentication(c)
+		ctx := app.NewContext(c, b.AppConfig)
+
+		defer b.recoverPanic(ctx)
+
+		// the whole body is held in memory to be hashed
+		limit := b.AppConfig.AuthConfig.SignatureMaxBodySize
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			b.renderError(ctx, exception.PayloadTooLarge(limit))
+			return
+		}
 		if err != nil {
-			logrus.Errorln(fmt.Sprintf("REQUEST ID: %s , message: Unauthorized", ctx.APIReqID))
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  http.StatusUnauthorized,
-				"message": "Unauthorized",
-				"data":    err.Error(),
-			})
+			b.renderError(ctx, exception.InvalidJSON("failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, err := b.Signatures.Verify(ctx, &signature.Request{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.RequestURI(),
+			Body:      body,
+			ClientKey: c.GetHeader(signature.HeaderClientKey),
+			Timestamp: c.GetHeader(signature.HeaderTimestamp),
+			Nonce:     c.GetHeader(signature.HeaderNonce),
+			Signature: c.GetHeader(signature.HeaderSignature),
+		})
+		if err != nil {
+			exc := signatureException(err)
+			exc.Cause = fmt.Errorf("client %s: %w", c.GetHeader(signature.HeaderClientKey), err)
+			b.renderError(ctx, exc)
 			return
 		}
 
-		defer func() {
-			if err0 := recover(); err0 != nil {
-				logrus.Errorln(err0)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"status":  http.StatusInternalServerError,
-					"message": "Request is halted unexpectedly, please contact the administrator.",
-					"data":    nil,
-				})
+		for _, scope := range scopes {
+			if !policy.Contains(key.Scopes, scope) {
+				exc := exception.PermissionDenied("requires scope " + scope)
+				exc.Cause = fmt.Errorf("client %s is missing scope %s", key.ClientID, scope)
+				b.renderError(ctx, exc)
+				return
 			}
-		}()
+		}
+
+		ctx.Set("client_id", key.ClientID)
+		ctx.Set("scope", strings.Join(key.Scopes, " "))
+
+		b.writeResponse(ctx, start, handler(ctx))
+	}
+}
 
+// signatureException maps a failed signature check to its B2B error.
+func signatureException(err error) *exception.Exception {
+	switch {
+	case errors.Is(err, signature.ErrMissingClientKey):
+		return exception.RequiredField("clientId")
+	case errors.Is(err, signature.ErrMissingTimestamp):
+		return exception.RequiredField("timestamp")
+	case errors.Is(err, signature.ErrTimestampLength):
+		return exception.InvalidFieldLength("timestamp", signature.MaxTimestampLength)
+	case errors.Is(err, signature.ErrInvalidTimestamp), errors.Is(err, signature.ErrTimestampSkew):
+		return exception.InvalidFieldFormat("Timestamp")
+	case errors.Is(err, signature.ErrMissingSignature):
+		return exception.RequiredField("signature")
+	case errors.Is(err, signature.ErrUnknownClient), errors.Is(err, signature.ErrUnsupportedKeyType):
+		return exception.InvalidPublicKey()
+	case errors.Is(err, signature.ErrInvalidSignature), errors.Is(err, signature.ErrReplayed):
+		return exception.InvalidSignature()
+	default:
+		return exception.Internal("failed to verify signature", err).WithResponseCode("500")
+	}
+}
+
+// SignResponse opts a route into signed responses: the run action signs the
+// serialized body, X-TIMESTAMP and X-API-Request-ID with the service key and
+// sends the result in X-SIGNATURE. Partners check it with
+// signature.VerifyHTTPResponse. Registering such a route without a signing
+// key stops the boot, partners must never receive an unsigned response.
+func (b BaseHTTPHandler) SignResponse(handler HandlerFnInterface) HandlerFnInterface {
+	if b.ResponseSigner == nil {
+		logrus.Fatalln("a route signs its responses but RESPONSE_SIGNING_KEY_FILE is not set")
+	}
+	return func(ctx *app.Context) *server.ResponseInterface {
 		resp := handler(ctx)
-		httpStatus := resp.Status
+		if resp != nil {
+			resp.Signed = true
+		}
+		return resp
+	}
+}
+
+// writeResponse turns a handler result into the response: resp.Err is
+// rendered by errorBody, Data is wrapped in the envelope unless the handler
+// asked for a raw body, and the body is signed when the route asked for it.
+// SignResponse already made sure a signing key is configured then.
+func (b BaseHTTPHandler) writeResponse(ctx *app.Context, start time.Time, resp *server.ResponseInterface) {
+	c := ctx.Context
+	// the handler already wrote the body itself, e.g. a served file
+	if c.Writer.Written() {
+		return
+	}
+	c.Header(headerRequestID, ctx.APIReqID)
+	switch {
+	case resp.Err != nil:
+		resp.Status, resp.Data = resp.Err.HTTPStatus(), b.errorBody(ctx, resp.Err)
+	case resp.Status == http.StatusNoContent:
+		resp.Data = nil
+	case !resp.Raw:
+		resp.Data = b.envelopeBody(ctx, resp)
+	}
+	if resp.Data == nil {
+		c.Status(resp.Status)
+		return
+	}
+	end := time.Now().Sub(start)
+	logrus.Infoln(fmt.Sprintf("REQUEST ID: %s , LATENCY: %vms", ctx.APIReqID, end.Milliseconds()))
+
+	if !resp.Signed {
+		c.JSON(resp.Status, resp.Data)
+		return
+	}
+	body, err := json.Marshal(resp.Data)
+	if err != nil {
+		b.renderError(ctx, exception.Internal("failed to encode response", err))
+		return
+	}
+	header, err := b.ResponseSigner.Sign(body, ctx.APIReqID)
+	if err != nil {
+		b.renderError(ctx, exception.Internal("failed to sign response", err))
+		return
+	}
+	for name := range header {
+		c.Header(name, header.Get(name))
+	}
+	c.Data(resp.Status, "application/json; charset=utf-8", body)
+}
+
+func (b BaseHTTPHandler) GuestAuthentication(c *gin.Context) (*app.Context, error) {
+	return app.NewContext(c, b.AppConfig), nil
+}
 
-		if resp.Data == nil {
-			c.Status(httpStatus)
+func (b BaseHTTPHandler) GuestRunAction(handler HandlerFnInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx, err := b.GuestAuthentication(c)
+		if err != nil {
+			exc := exception.Unauthenticated("Unauthorized")
+			exc.Cause = err
+			b.renderError(ctx, exc)
 			return
 		}
-		end := time.Now().Sub(start)
-		logrus.Infoln(fmt.Sprintf("REQUEST ID: %s , LATENCY: %vms", ctx.APIReqID, end.Milliseconds()))
-		c.JSON(httpStatus, resp.Data)
 
+		defer b.recoverPanic(ctx)
+
+		b.writeResponse(ctx, start, handler(ctx))
 	}
 }
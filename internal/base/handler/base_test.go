@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/base/app"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderBody renders exc like a failed run action and decodes the body.
+func renderBody(t *testing.T, debug, format string, exc *exception.Exception) map[string]any {
+	config := &appconf.Config{AppEnvConfig: &appconf.AppConfig{AppDebug: debug, ResponseFormat: format}}
+	b := BaseHTTPHandler{AppConfig: config}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v2/settings", nil)
+	c.Request.Header.Set(headerRequestID, "request-1")
+	b.renderError(app.NewContext(c, config), exc)
+
+	assert.Equal(t, exc.HTTPStatus(), recorder.Code)
+	assert.Equal(t, "request-1", recorder.Header().Get(headerRequestID))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return body
+}
+
+func TestErrorBodyHidesDetailsOutsideDebug(t *testing.T) {
+	internal := exception.Internal("failed to find settings", errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	require.NotEmpty(t, internal.Stack)
+	conflict := exception.Conflict("settings were changed by another request, please retry")
+	conflict.Cause = errors.New("duplicate key value violates unique constraint")
+
+	for _, format := range []string{"envelope", "legacy"} {
+		for _, exc := range []*exception.Exception{internal, conflict} {
+			body := renderBody(t, "False", format, exc)
+			assert.NotContains(t, body, "error", "%s: the cause is hidden", format)
+			assert.NotContains(t, body, "stack_trace", "%s: the stack is hidden", format)
+		}
+	}
+
+	body := renderBody(t, "False", "envelope", internal)
+	assert.Equal(t, float64(http.StatusInternalServerError), body["status"])
+	assert.Equal(t, "5000000", body["response_code"])
+	assert.Equal(t, "failed to find settings", body["message"])
+	assert.Equal(t, "request-1", body["request_id"])
+}
+
+func TestErrorBodyShowsDetailsInDebug(t *testing.T) {
+	exc := exception.Internal("failed to find settings", errors.New("connection refused"))
+
+	for _, format := range []string{"envelope", "legacy"} {
+		body := renderBody(t, "True", format, exc)
+		assert.Equal(t, "connection refused", body["error"], format)
+		assert.Equal(t, exc.Stack, body["stack_trace"], format)
+	}
+}
+
+func TestErrorBodyKeepsValidationFields(t *testing.T) {
+	body := renderBody(t, "False", "envelope", exception.Validation(map[string]string{"currency": "currency is required"}))
+	assert.Equal(t, float64(http.StatusBadRequest), body["status"])
+	assert.Equal(t, map[string]any{"currency": "currency is required"}, body["errors"])
+	assert.Equal(t, "currency is required", body["message"])
+}
+
+func TestErrorBodyLegacyResponseCode(t *testing.T) {
+	body := renderBody(t, "False", "legacy", exception.Internal("failed to find settings", errors.New("connection refused")))
+	assert.Equal(t, float64(http.StatusInternalServerError), body["responseCode"], "the HTTP status like the original body")
+	assert.Equal(t, "failed to find settings", body["responseMessage"])
+
+	body = renderBody(t, "False", "envelope", exception.PayloadTooLarge(1<<20))
+	assert.Equal(t, "4130000", body["responseCode"], "SNAP errors keep the business code")
+}
+
+func TestSuccessBodyMessage(t *testing.T) {
+	config := &appconf.Config{AppEnvConfig: &appconf.AppConfig{AppDebug: "False"}}
+	b := BaseHTTPHandler{AppConfig: config}
+
+	for format, key := range map[string]string{"envelope": "message", "legacy": "responseMessage"} {
+		for _, resp := range []*server.ResponseInterface{
+			b.AsData(nil, http.StatusOK, MessageSuccess, map[string]any{"id": 1}),
+			{Status: http.StatusCreated, Data: map[string]any{"id": 1}},
+		} {
+			gin.SetMode(gin.TestMode)
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v2/settings", nil)
+			c.Request.Header.Set(headerResponseFormat, format)
+			b.writeResponse(app.NewContext(c, config), time.Now(), resp)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, MessageSuccess, body[key], "%s: one message for every success", format)
+		}
+	}
+}
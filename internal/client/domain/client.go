@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	TableName = "api_clients"
+
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// Client is a machine caller authenticating with the client_credentials
+// grant. Only the bcrypt hash of the secret is stored.
+type Client struct {
+	ID         int64  `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	ClientID   string `gorm:"size:64;uniqueIndex;not null" json:"client_id"`
+	Name       string `gorm:"size:255;not null" json:"name"`
+	SecretHash string `gorm:"size:255;not null" json:"-"`
+	Scopes     string `gorm:"size:1000" json:"-"`
+	// PublicKey is the PEM encoded RSA or ECDSA key verifying the signed
+	// requests of the client.
+	PublicKey  string     `gorm:"type:text" json:"public_key,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (model *Client) TableName() string {
+	return os.Getenv("DB_PREFIX") + TableName
+}
+
+// ScopeList returns the scopes granted to the client.
+func (model *Client) ScopeList() []string {
+	return strings.Fields(model.Scopes)
+}
+
+// CreateClientRequest registers a client, the secret is generated.
+type CreateClientRequest struct {
+	Name   string   `json:"name" validate:"required,max=255" name:"name"`
+	Scopes []string `json:"scopes" validate:"dive,required,excludesall= " name:"scopes"`
+}
+
+// PublicKeyRequest registers the key verifying the signed requests of a
+// client.
+type PublicKeyRequest struct {
+	PublicKey string `json:"public_key" validate:"required" name:"public_key"`
+}
+
+// ClientResponse is a client as listed to admins.
+type ClientResponse struct {
+	*Client
+	Scopes []string `json:"scopes"`
+}
+
+// CreateClientResponse carries the only copy of the plain secret.
+type CreateClientResponse struct {
+	ClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+// TokenRequest is the OAuth2 token request, form encoded as in RFC 6749 or
+// JSON. The client may also authenticate with HTTP Basic.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+	Scope        string `form:"scope" json:"scope"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/client/domain"
+	ClientService "boiler-plate/internal/client/service"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+)
+
+type HTTPHandler struct {
+	App           *handler.BaseHTTPHandler
+	ClientService ClientService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, clientService ClientService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:           handler,
+		ClientService: clientService,
+	}
+}
+
+// Token is the OAuth2 token endpoint for the client_credentials grant. The
+// success body is the bare RFC 6749 token response, errors use the SNAP
+// body.
+func (h HTTPHandler) Token(ctx *app.Context) *server.ResponseInterface {
+	var request domain.TokenRequest
+	if err := ctx.ShouldBind(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidJSON(err.Error()))
+	}
+	if clientID, clientSecret, ok := ctx.Request.BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientID, clientSecret
+	}
+
+	switch {
+	case request.GrantType == "":
+		return h.App.AsException(ctx, exception.RequiredGrantType())
+	case request.GrantType != domain.GrantTypeClientCredentials:
+		return h.App.AsException(ctx, exception.UnsupportedGrantType())
+	case request.ClientID == "":
+		return h.App.AsException(ctx, exception.RequiredField("clientId"))
+	case request.ClientSecret == "":
+		return h.App.AsException(ctx, exception.RequiredField("clientSecret"))
+	}
+
+	result, exc := h.ClientService.Token(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	ctx.Header("Cache-Control", "no-store")
+	return h.App.AsJsonInterface(ctx, http.StatusOK, result)
+}
+
+func (h HTTPHandler) Create(ctx *app.Context) *server.ResponseInterface {
+	var request domain.CreateClientRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.ClientService.Create(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusCreated, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Find(ctx *app.Context) *server.ResponseInterface {
+	result, exc := h.ClientService.Find(ctx)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Revoke(ctx *app.Context) *server.ResponseInterface {
+	if exc := h.ClientService.Revoke(ctx, ctx.Param("client_id")); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
+
+func (h HTTPHandler) SetPublicKey(ctx *app.Context) *server.ResponseInterface {
+	var request domain.PublicKeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.ClientService.SetPublicKey(ctx, ctx.Param("client_id"), &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
+
+// Me returns the client and scopes of the caller, partners use it to check
+// their credentials or request signing.
+func (h HTTPHandler) Me(ctx *app.Context) *server.ResponseInterface {
+	scope, _ := ctx.Get("scope")
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, map[string]interface{}{
+		"client_id": ctx.ClientID(),
+		"scope":     scope,
+	})
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"boiler-plate/internal/client/domain"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, model *domain.Client) error
+	Find(ctx context.Context) ([]domain.Client, error)
+	FindByClientID(ctx context.Context, clientID string) (*domain.Client, error)
+	Touch(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, id int64) error
+	UpdatePublicKey(ctx context.Context, id int64, publicKey string) error
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"boiler-plate/internal/client/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db   *gorm.DB
+	base *baseModel.SQLClientRepository
+}
+
+func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
+	return &Repo{db: db, base: base}
+}
+
+func (r Repo) Create(ctx context.Context, model *domain.Client) error {
+	if err := r.db.WithContext(ctx).
+		Create(model).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (r Repo) Find(ctx context.Context) ([]domain.Client, error) {
+	var (
+		models []domain.Client
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Client{}).
+		Order("id asc").
+		Find(&models).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindByClientID(ctx context.Context, clientID string) (*domain.Client, error) {
+	var (
+		models *domain.Client
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Client{}).
+		Where("client_id = ?", clientID).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+// Touch records that the client obtained a token.
+func (r Repo) Touch(ctx context.Context, id int64) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Client{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now()).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Revoke disables the client, tokens it already holds expire on their own.
+func (r Repo) Revoke(ctx context.Context, id int64) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Client{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (r Repo) UpdatePublicKey(ctx context.Context, id int64, publicKey string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Client{}).
+		Where("id = ?", id).
+		Update("public_key", publicKey).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
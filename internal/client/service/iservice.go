@@ -0,0 +1,27 @@
+package service
+
+import (
+	"boiler-plate/internal/client/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+	"errors"
+)
+
+// Errors carried by the exceptions of Token, the handler answers them with
+// the matching client error helpers.
+var (
+	ErrInvalidClientID     = errors.New("invalid client id")
+	ErrInvalidClientSecret = errors.New("invalid client secret")
+)
+
+type Service interface {
+	// Token authenticates a client and issues a client_credentials token
+	// carrying the requested scopes, every granted scope when none is asked.
+	Token(ctx context.Context, req *domain.TokenRequest) (*domain.TokenResponse, *exception.Exception)
+	Create(ctx context.Context, req *domain.CreateClientRequest) (*domain.CreateClientResponse, *exception.Exception)
+	Find(ctx context.Context) ([]domain.ClientResponse, *exception.Exception)
+	Revoke(ctx context.Context, clientID string) *exception.Exception
+	// SetPublicKey registers the key verifying the signed requests of a
+	// client, replacing the previous one.
+	SetPublicKey(ctx context.Context, clientID string, req *domain.PublicKeyRequest) *exception.Exception
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"boiler-plate/internal/client/repository"
+	"boiler-plate/pkg/signature"
+	"context"
+)
+
+// NewKeyRegistry serves the public keys of active clients to the request
+// signature verifier.
+func NewKeyRegistry(repo repository.Repository) signature.KeyRegistry {
+	return &keyRegistry{clientRepo: repo}
+}
+
+type keyRegistry struct {
+	clientRepo repository.Repository
+}
+
+func (r keyRegistry) SignatureKey(ctx context.Context, clientID string) (*signature.Key, error) {
+	client, err := r.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.RevokedAt != nil || client.PublicKey == "" {
+		return nil, nil
+	}
+	publicKey, err := signature.ParsePublicKey(client.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &signature.Key{ClientID: client.ClientID, PublicKey: publicKey, Scopes: client.ScopeList()}, nil
+}
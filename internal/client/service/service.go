@@ -0,0 +1,177 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/client/domain"
+	"boiler-plate/internal/client/repository"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/signature"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewService creates new client service
+func NewService(
+	config *appconf.Config, repo repository.Repository, validate *xvalidator.Validator, keys *jwt.KeySet,
+) Service {
+	return &service{
+		config:     config,
+		clientRepo: repo,
+		validate:   validate,
+		keys:       keys,
+	}
+}
+
+type service struct {
+	config     *appconf.Config
+	clientRepo repository.Repository
+	validate   *xvalidator.Validator
+	keys       *jwt.KeySet
+}
+
+func (s service) Token(ctx context.Context, req *domain.TokenRequest) (*domain.TokenResponse, *exception.Exception) {
+	client, err := s.clientRepo.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, exception.Internal("failed to find client", err)
+	}
+	if client == nil || client.RevokedAt != nil {
+		exc := exception.InvalidClientID()
+		exc.Cause = ErrInvalidClientID
+		return nil, exc
+	}
+	if !password.CheckPassword(client.SecretHash, req.ClientSecret) {
+		logrus.WithField("client_id", client.ClientID).Warnln("client authentication failed")
+		exc := exception.InvalidClientSecret()
+		exc.Cause = ErrInvalidClientSecret
+		return nil, exc
+	}
+
+	scopes := client.ScopeList()
+	if req.Scope != "" {
+		granted := map[string]bool{}
+		for _, scope := range scopes {
+			granted[scope] = true
+		}
+		scopes = strings.Fields(req.Scope)
+		for _, scope := range scopes {
+			if !granted[scope] {
+				return nil, exception.PermissionDenied("scope " + scope + " is not granted to the client")
+			}
+		}
+	}
+
+	ttl := s.config.AuthConfig.ClientTokenTTL
+	scope := strings.Join(scopes, " ")
+	token, err := jwt.ClientJWT(&jwt.ClientClaims{ClientID: client.ClientID, Scope: scope}, ttl, s.keys)
+	if err != nil {
+		return nil, exception.Internal("failed to sign token", err)
+	}
+	if err := s.clientRepo.Touch(ctx, client.ID); err != nil {
+		logrus.Errorf("failed to update client last use: %v", err)
+	}
+	return &domain.TokenResponse{
+		AccessToken: token,
+		TokenType:   "bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+func (s service) Create(
+	ctx context.Context, req *domain.CreateClientRequest,
+) (*domain.CreateClientResponse, *exception.Exception) {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+
+	id, err := randomString(12, hex.EncodeToString)
+	if err != nil {
+		return nil, exception.Internal("failed to generate client id", err)
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, exception.Internal("failed to generate client secret", err)
+	}
+	client := &domain.Client{
+		ClientID:   id,
+		Name:       req.Name,
+		SecretHash: password.HashPassword(secret),
+		Scopes:     strings.Join(req.Scopes, " "),
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, exception.Internal("failed to create client", err)
+	}
+	return &domain.CreateClientResponse{
+		ClientResponse: clientResponse(client),
+		ClientSecret:   secret,
+	}, nil
+}
+
+func (s service) Find(ctx context.Context) ([]domain.ClientResponse, *exception.Exception) {
+	clients, err := s.clientRepo.Find(ctx)
+	if err != nil {
+		return nil, exception.Internal("failed to find clients", err)
+	}
+	result := make([]domain.ClientResponse, 0, len(clients))
+	for i := range clients {
+		result = append(result, clientResponse(&clients[i]))
+	}
+	return result, nil
+}
+
+func (s service) Revoke(ctx context.Context, clientID string) *exception.Exception {
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return exception.Internal("failed to find client", err)
+	}
+	if client == nil {
+		return exception.NotFound("client not found")
+	}
+	if err := s.clientRepo.Revoke(ctx, client.ID); err != nil {
+		return exception.Internal("failed to revoke client", err)
+	}
+	return nil
+}
+
+func (s service) SetPublicKey(
+	ctx context.Context, clientID string, req *domain.PublicKeyRequest,
+) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+	if _, err := signature.ParsePublicKey(req.PublicKey); err != nil {
+		return exception.InvalidArgument(err.Error())
+	}
+
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return exception.Internal("failed to find client", err)
+	}
+	if client == nil {
+		return exception.NotFound("client not found")
+	}
+	if err := s.clientRepo.UpdatePublicKey(ctx, client.ID, req.PublicKey); err != nil {
+		return exception.Internal("failed to update public key", err)
+	}
+	return nil
+}
+
+func clientResponse(client *domain.Client) domain.ClientResponse {
+	return domain.ClientResponse{Client: client, Scopes: client.ScopeList()}
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,28 @@
+package consumer
+
+import (
+	"boiler-plate/internal/customer/domain"
+	CustomerService "boiler-plate/internal/customer/service"
+	"boiler-plate/pkg/consumer"
+	"context"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// NewUpdateCustomerConsumer applies update customer events to the local
+// projection. Invalid events, unknown customers and phone numbers already
+// owned by another account go to the dead-letter topic, other failures are
+// retried.
+func NewUpdateCustomerConsumer(
+	reader consumer.Reader, customerService CustomerService.Service, config *consumer.Config, registry *consumer.Registry,
+) *consumer.Consumer[domain.UpdateCustomerEvent] {
+	handler := func(ctx context.Context, event *domain.UpdateCustomerEvent, _ kafka.Message) error {
+		err := customerService.HandleUpdateCustomer(ctx, event)
+		if errors.Is(err, domain.ErrInvalidEvent) {
+			return consumer.Permanent(err)
+		}
+		return err
+	}
+	return consumer.New(reader, handler, config, registry)
+}
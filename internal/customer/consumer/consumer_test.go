@@ -0,0 +1,117 @@
+package consumer_test
+
+import (
+	CustomerConsumer "boiler-plate/internal/customer/consumer"
+	"boiler-plate/internal/customer/domain"
+	"boiler-plate/internal/events"
+	"boiler-plate/pkg/broker/memkafka"
+	"boiler-plate/pkg/consumer"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	topic    = "update-customer"
+	dlqTopic = "update-customer-dlq"
+	groupID  = "boiler-plate"
+)
+
+// fakeService fails the customers listed in errs once per entry.
+type fakeService struct {
+	mu      sync.Mutex
+	errs    map[int64][]error
+	handled []int64
+}
+
+func (s *fakeService) HandleUpdateCustomer(ctx context.Context, event *domain.UpdateCustomerEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if errs := s.errs[event.CustomerID]; len(errs) > 0 {
+		s.errs[event.CustomerID] = errs[1:]
+		return errs[0]
+	}
+	s.handled = append(s.handled, event.CustomerID)
+	return nil
+}
+
+func (s *fakeService) SyncCustomer(ctx context.Context, customerID int64) (*domain.Customer, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestUpdateCustomerConsumer(t *testing.T) {
+	registry, err := events.NewRegistry()
+	require.NoError(t, err)
+	broker := memkafka.New(2)
+	defer broker.Close()
+
+	service := &fakeService{errs: map[int64][]error{
+		// a phone number owned by another account
+		2: {fmt.Errorf("%w: duplicate key", domain.ErrInvalidEvent)},
+		3: {errors.New("customer service unavailable")},
+	}}
+	for _, value := range []string{`{"customer_id":1}`, `{"customer_id":2}`, `{"customer_id":3}`, `{"customer_id":0}`} {
+		require.NoError(t, broker.Publish(context.Background(), topic, kafka.Message{Key: []byte(value), Value: []byte(value)}))
+	}
+
+	config := &consumer.Config{
+		Topic:           topic,
+		DeadLetterTopic: dlqTopic,
+		Publisher:       broker,
+		MaxRetries:      3,
+		MinBackoff:      time.Millisecond,
+		MaxBackoff:      time.Millisecond,
+		Registry:        registry,
+		EventType:       events.CustomerUpdated,
+	}
+	metrics := consumer.NewRegistry()
+	c := CustomerConsumer.NewUpdateCustomerConsumer(broker.NewReader(topic, groupID), service, config, metrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		return broker.Lag(groupID, topic) == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	service.mu.Lock()
+	assert.ElementsMatch(t, []int64{1, 3}, service.handled, "the transient failure is retried")
+	service.mu.Unlock()
+
+	dead := broker.Messages(dlqTopic)
+	require.Len(t, dead, 2)
+	reasons := map[string]string{}
+	for _, msg := range dead {
+		headers := map[string]string{}
+		for _, header := range msg.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+		assert.Equal(t, topic, headers[consumer.HeaderOriginalTopic])
+		assert.Equal(t, "1", headers[consumer.HeaderAttempts])
+		reasons[string(msg.Value)] = headers[consumer.HeaderReason]
+	}
+	assert.Equal(t, map[string]string{
+		`{"customer_id":2}`: consumer.ReasonPermanent,
+		`{"customer_id":0}`: consumer.ReasonPermanent,
+	}, reasons)
+
+	topicMetrics := metrics.Metrics()[topic]
+	assert.Equal(t, int64(2), topicMetrics.Processed)
+	assert.Equal(t, int64(2), topicMetrics.DeadLettered)
+	assert.Equal(t, int64(1), topicMetrics.Retried)
+
+	var committed int64
+	for p := 0; p < 2; p++ {
+		committed += broker.CommittedOffset(groupID, topic, p)
+	}
+	assert.Equal(t, int64(4), committed)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	CustomerTableName = "customers"
+)
+
+// ErrInvalidEvent marks an update customer event that can never be
+// processed, retrying it is pointless.
+var ErrInvalidEvent = errors.New("invalid update customer event")
+
+// Customer is the local projection of an investor owned by the customer
+// service. It is kept up to date from update customer events.
+type Customer struct {
+	ID                 int64     `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	CustomerID         int64     `gorm:"uniqueIndex;not null" json:"customer_id"`
+	AccountID          *int64    `gorm:"index" json:"account_id"`
+	Fullname           string    `gorm:"size:150" json:"fullname"`
+	Email              string    `gorm:"size:150;index" json:"email"`
+	PhoneNumber        string    `gorm:"size:30" json:"phone_number"`
+	InvestorCategoryID *int64    `json:"investor_category_id"`
+	InvestorCategory   string    `gorm:"size:150" json:"investor_category"`
+	Status             string    `gorm:"size:50" json:"status"`
+	SourceUpdatedAt    time.Time `json:"source_updated_at"`
+	SyncedAt           time.Time `json:"synced_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (model *Customer) TableName() string {
+	return os.Getenv("DB_PREFIX") + CustomerTableName
+}
+
+// UpdateCustomerEvent is published on KafkaUpdateCustomerTopic. Only the
+// customer ID is relied on, the current state is read from the customer
+// service.
+type UpdateCustomerEvent struct {
+	CustomerID int64 `json:"customer_id"`
+}
+
+func (event *UpdateCustomerEvent) Validate() error {
+	if event.CustomerID <= 0 {
+		return fmt.Errorf("%w: customer_id is required", ErrInvalidEvent)
+	}
+	return nil
+}
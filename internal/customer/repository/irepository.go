@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"boiler-plate/internal/customer/domain"
+	"context"
+)
+
+type Repository interface {
+	// Upsert stores model unless a newer version of the customer is stored
+	// already. It reports whether model was written.
+	Upsert(ctx context.Context, model *domain.Customer) (bool, error)
+}
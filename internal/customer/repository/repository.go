@@ -0,0 +1,75 @@
+package repository
+
+import (
+	accountDomain "boiler-plate/internal/account/domain"
+	"boiler-plate/internal/customer/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db   *gorm.DB
+	base *baseModel.SQLClientRepository
+}
+
+func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
+	return &Repo{db: db, base: base}
+}
+
+// Upsert also copies the name and phone number onto the local account with
+// the same email, linking the projection to it. Empty values are not copied
+// and a verified phone number is never replaced by an unverified one from the
+// customer service.
+func (r Repo) Upsert(ctx context.Context, model *domain.Customer) (bool, error) {
+	written := false
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing domain.Customer
+		err := tx.Where("customer_id = ?", model.CustomerID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			return err
+		case existing.SourceUpdatedAt.After(model.SourceUpdatedAt):
+			return nil
+		default:
+			model.ID = existing.ID
+			model.CreatedAt = existing.CreatedAt
+		}
+
+		var account accountDomain.Account
+		err = tx.Where("email = ?", accountDomain.NormalizeEmail(model.Email)).First(&account).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			model.AccountID = nil
+		case err != nil:
+			return err
+		default:
+			model.AccountID = &account.ID
+			updates := map[string]interface{}{}
+			if model.Fullname != "" {
+				updates["fullname"] = model.Fullname
+			}
+			if model.PhoneNumber != "" && model.PhoneNumber != account.Phone && account.PhoneVerifiedAt == nil {
+				updates["phone"] = model.PhoneNumber
+			}
+			if len(updates) > 0 {
+				if err := tx.Model(&account).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Save(model).Error; err != nil {
+			return err
+		}
+		written = true
+		return nil
+	}); err != nil {
+		return false, errs.Wrap(err)
+	}
+	return written, nil
+}
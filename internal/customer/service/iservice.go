@@ -0,0 +1,13 @@
+package service
+
+import (
+	"boiler-plate/internal/customer/domain"
+	"context"
+)
+
+type Service interface {
+	// HandleUpdateCustomer processes a decoded update customer event. Errors
+	// wrapping domain.ErrInvalidEvent must not be retried.
+	HandleUpdateCustomer(ctx context.Context, event *domain.UpdateCustomerEvent) error
+	SyncCustomer(ctx context.Context, customerID int64) (*domain.Customer, error)
+}
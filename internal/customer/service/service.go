@@ -0,0 +1,75 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/customer/domain"
+	"boiler-plate/internal/customer/repository"
+	"boiler-plate/pkg/customerservice"
+	baseModel "boiler-plate/pkg/db"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewService creates new customer service
+func NewService(
+	config *appconf.Config, repo repository.Repository, customerClient *customerservice.Client,
+) Service {
+	return &service{
+		config:         config,
+		customerRepo:   repo,
+		customerClient: customerClient,
+	}
+}
+
+type service struct {
+	config         *appconf.Config
+	customerRepo   repository.Repository
+	customerClient *customerservice.Client
+}
+
+func (s service) HandleUpdateCustomer(ctx context.Context, event *domain.UpdateCustomerEvent) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.SyncCustomer(ctx, event.CustomerID)
+	// a phone number owned by another account fails the same way on every
+	// retry, like an unknown customer
+	if errors.Is(err, customerservice.ErrNotFound) || baseModel.IsDuplicateKey(err) {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidEvent, err)
+	}
+	return err
+}
+
+// SyncCustomer reads the investor from the customer service and stores it in
+// the local projection.
+func (s service) SyncCustomer(ctx context.Context, customerID int64) (*domain.Customer, error) {
+	detail, err := s.customerClient.GetInvestorDetail(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &domain.Customer{
+		CustomerID:         detail.CustomerID,
+		Fullname:           detail.Fullname,
+		Email:              detail.Email,
+		PhoneNumber:        detail.PhoneNumber,
+		InvestorCategoryID: detail.InvestorCategoryID,
+		InvestorCategory:   detail.InvestorCategory,
+		Status:             detail.Status,
+		SourceUpdatedAt:    detail.UpdatedAt,
+		SyncedAt:           time.Now(),
+	}
+	written, err := s.customerRepo.Upsert(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	if !written {
+		logrus.WithField("customer_id", customerID).Debugln("skipped stale customer update")
+	}
+	return model, nil
+}
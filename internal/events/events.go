@@ -0,0 +1,34 @@
+package events
+
+import (
+	"boiler-plate/pkg/event"
+	"embed"
+	"io/fs"
+)
+
+// Event types published or consumed by this service. Their schemas live in
+// schemas/<type>/v<version>.json, add a new version file instead of editing
+// one that was released.
+const (
+	SettingsChanged          = "settings.changed"
+	SettingsChangedVersion   = 1
+	AccountRegistered        = "account.registered"
+	AccountRegisteredVersion = 1
+	CustomerUpdated          = "customer.updated"
+)
+
+//go:embed schemas
+var schemas embed.FS
+
+// NewRegistry loads every schema of the service.
+func NewRegistry() (*event.Registry, error) {
+	fsys, err := fs.Sub(schemas, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	registry := event.NewRegistry()
+	if err := registry.LoadFS(fsys); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
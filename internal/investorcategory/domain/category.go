@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	InvestorCategoryTableName = "investor_categories"
+)
+
+// FilterFields and SortFields are the columns accepted from the filter and
+// sort query parameters.
+var (
+	FilterFields = []string{"id", "name", "description", "created_at", "updated_at"}
+	SortFields   = []string{"id", "name", "created_at", "updated_at"}
+)
+
+type InvestorCategory struct {
+	ID          int64          `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Name        string         `gorm:"size:150;index;not null" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (model *InvestorCategory) TableName() string {
+	return os.Getenv("DB_PREFIX") + InvestorCategoryTableName
+}
+
+type InvestorCategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=150" name:"name"`
+	Description string `json:"description" validate:"max=1000" name:"description"`
+}
+
+type InvestorCategorySelect struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/investorcategory/domain"
+	InvestorCategoryService "boiler-plate/internal/investorcategory/service"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/getfilter"
+	"boiler-plate/pkg/server"
+	"net/http"
+	"strconv"
+)
+
+type HTTPHandler struct {
+	App                     *handler.BaseHTTPHandler
+	InvestorCategoryService InvestorCategoryService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, investorCategoryService InvestorCategoryService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:                     handler,
+		InvestorCategoryService: investorCategoryService,
+	}
+}
+
+func (h HTTPHandler) Create(ctx *app.Context) *server.ResponseInterface {
+	var request domain.InvestorCategoryRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.InvestorCategoryService.Create(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Find(ctx *app.Context) *server.ResponseInterface {
+	filter := getfilter.Initiate(ctx.Context)
+	if !getfilter.Validation(filter) {
+		return h.App.AsException(ctx, exception.InvalidArgument("invalid filter operator"))
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	paginate := baseModel.NewPaginate(limit, page, filter.ArrSort)
+
+	result, exc := h.InvestorCategoryService.Find(ctx, filter.ArrQuery, paginate)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsPage(ctx, http.StatusOK, handler.MessageSuccess, result, paginate)
+}
+
+func (h HTTPHandler) FindByID(ctx *app.Context) *server.ResponseInterface {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument("id must be a number"))
+	}
+
+	result, exc := h.InvestorCategoryService.FindByID(ctx, id)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) FindSelect(ctx *app.Context) *server.ResponseInterface {
+	result, exc := h.InvestorCategoryService.FindSelect(ctx)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Delete(ctx *app.Context) *server.ResponseInterface {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument("id must be a number"))
+	}
+
+	if exc := h.InvestorCategoryService.Delete(ctx, id); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
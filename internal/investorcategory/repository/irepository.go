@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"boiler-plate/internal/investorcategory/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/getfilter"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, model *domain.InvestorCategory) error
+	Find(ctx context.Context, filters []getfilter.FilterItem, paginate *baseModel.Paginate) ([]domain.InvestorCategory, error)
+	FindByID(ctx context.Context, id int64) (*domain.InvestorCategory, error)
+	FindByName(ctx context.Context, name string) (*domain.InvestorCategory, error)
+	FindSelect(ctx context.Context) ([]domain.InvestorCategorySelect, error)
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"boiler-plate/internal/investorcategory/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"boiler-plate/pkg/getfilter"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db   *gorm.DB
+	base *baseModel.SQLClientRepository
+}
+
+func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
+	return &Repo{db: db, base: base}
+}
+
+func (r Repo) Create(ctx context.Context, model *domain.InvestorCategory) error {
+	if err := r.db.WithContext(ctx).
+		Create(model).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Find lists categories. Filter fields and sort columns must already be
+// checked against domain.FilterFields and domain.SortFields.
+func (r Repo) Find(
+	ctx context.Context, filters []getfilter.FilterItem, paginate *baseModel.Paginate,
+) ([]domain.InvestorCategory, error) {
+	var (
+		models []domain.InvestorCategory
+	)
+	query := r.db.WithContext(ctx).Model(&domain.InvestorCategory{})
+	for _, filter := range filters {
+		switch filter.Operator {
+		case "in":
+			query = query.Where(fmt.Sprintf("%s IN ?", filter.Field), getfilter.GenerateWhere(filter))
+		case "like":
+			query = query.Where(fmt.Sprintf("LOWER(%s) LIKE ?", filter.Field), getfilter.GenerateWhere(filter)...)
+		case "is":
+			if strings.EqualFold(filter.Value, "null") {
+				query = query.Where(fmt.Sprintf("%s IS NULL", filter.Field))
+			} else {
+				query = query.Where(fmt.Sprintf("%s IS NOT NULL", filter.Field))
+			}
+		default:
+			query = query.Where(fmt.Sprintf("%s %s ?", filter.Field, filter.Operator), getfilter.GenerateWhere(filter)...)
+		}
+	}
+	paginated := paginate.PaginatedResult(&domain.InvestorCategory{}, query.Session(&gorm.Session{}))
+
+	sorts := strings.Split(strings.TrimSuffix(paginate.Sort, ","), ",")
+	orders := strings.Split(strings.TrimSuffix(paginate.Order, ","), ",")
+	ordered := false
+	for i, sort := range sorts {
+		if sort == "" {
+			continue
+		}
+		direction := "asc"
+		if i < len(orders) && strings.EqualFold(orders[i], "desc") {
+			direction = "desc"
+		}
+		query = query.Order(sort + " " + direction)
+		ordered = true
+	}
+	if !ordered {
+		query = query.Order("id desc")
+	}
+
+	if err := query.
+		Scopes(paginated).
+		Find(&models).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindByID(ctx context.Context, id int64) (*domain.InvestorCategory, error) {
+	var (
+		models *domain.InvestorCategory
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.InvestorCategory{}).
+		Where("id = ?", id).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindByName(ctx context.Context, name string) (*domain.InvestorCategory, error) {
+	var (
+		models *domain.InvestorCategory
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.InvestorCategory{}).
+		Where("LOWER(name) = ?", strings.ToLower(name)).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindSelect(ctx context.Context) ([]domain.InvestorCategorySelect, error) {
+	var (
+		models []domain.InvestorCategorySelect
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.InvestorCategory{}).
+		Select("id", "name").
+		Order("id desc").
+		Find(&models).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) Delete(ctx context.Context, id int64) error {
+	if err := r.db.WithContext(ctx).
+		Delete(&domain.InvestorCategory{}, id).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"boiler-plate/internal/investorcategory/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/getfilter"
+	"context"
+)
+
+type Service interface {
+	Create(ctx context.Context, req *domain.InvestorCategoryRequest) (*domain.InvestorCategory, *exception.Exception)
+	Find(ctx context.Context, filters []getfilter.FilterItem, paginate *baseModel.Paginate) ([]domain.InvestorCategory, *exception.Exception)
+	FindByID(ctx context.Context, id int64) (*domain.InvestorCategory, *exception.Exception)
+	FindSelect(ctx context.Context) ([]domain.InvestorCategorySelect, *exception.Exception)
+	Delete(ctx context.Context, id int64) *exception.Exception
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/internal/investorcategory/domain"
+	"boiler-plate/internal/investorcategory/repository"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/getfilter"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"strings"
+)
+
+// NewService creates new investor category service
+func NewService(config *appconf.Config, repo repository.Repository, validate *xvalidator.Validator) Service {
+	return &service{
+		config:       config,
+		categoryRepo: repo,
+		validate:     validate,
+	}
+}
+
+type service struct {
+	config       *appconf.Config
+	categoryRepo repository.Repository
+	validate     *xvalidator.Validator
+}
+
+func (s service) Create(
+	ctx context.Context, req *domain.InvestorCategoryRequest,
+) (*domain.InvestorCategory, *exception.Exception) {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+
+	existing, err := s.categoryRepo.FindByName(ctx, req.Name)
+	if err != nil {
+		return nil, exception.Internal("failed to find investor category", err)
+	}
+	if existing != nil {
+		return nil, exception.Conflict("name already exists")
+	}
+
+	model := &domain.InvestorCategory{Name: req.Name, Description: req.Description}
+	if err := s.categoryRepo.Create(ctx, model); err != nil {
+		return nil, exception.Internal("failed to create investor category", err)
+	}
+	return model, nil
+}
+
+func (s service) Find(
+	ctx context.Context, filters []getfilter.FilterItem, paginate *baseModel.Paginate,
+) ([]domain.InvestorCategory, *exception.Exception) {
+	for _, filter := range filters {
+		if !contains(domain.FilterFields, filter.Field) {
+			return nil, exception.InvalidArgument("invalid filter field " + filter.Field)
+		}
+	}
+	for _, sort := range strings.Split(strings.TrimSuffix(paginate.Sort, ","), ",") {
+		if sort != "" && !contains(domain.SortFields, sort) {
+			return nil, exception.InvalidArgument("invalid sort field " + sort)
+		}
+	}
+
+	result, err := s.categoryRepo.Find(ctx, filters, paginate)
+	if err != nil {
+		return nil, exception.Internal("failed to find investor categories", err)
+	}
+	return result, nil
+}
+
+func (s service) FindByID(ctx context.Context, id int64) (*domain.InvestorCategory, *exception.Exception) {
+	result, err := s.categoryRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, exception.Internal("failed to find investor category", err)
+	}
+	if result == nil {
+		return nil, exception.NotFound("not found")
+	}
+	return result, nil
+}
+
+func (s service) FindSelect(ctx context.Context) ([]domain.InvestorCategorySelect, *exception.Exception) {
+	result, err := s.categoryRepo.FindSelect(ctx)
+	if err != nil {
+		return nil, exception.Internal("failed to find investor categories", err)
+	}
+	return result, nil
+}
+
+func (s service) Delete(ctx context.Context, id int64) *exception.Exception {
+	if _, exc := s.FindByID(ctx, id); exc != nil {
+		return exc
+	}
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return exception.Internal("failed to delete investor category", err)
+	}
+	return nil
+}
+
+func contains(arr []string, item string) bool {
+	for _, v := range arr {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
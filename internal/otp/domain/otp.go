@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	OTPTableName = "otps"
+
+	PurposeLogin        = "login"
+	PurposeRegistration = "registration"
+
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// OTP is a one time password sent to an email address or phone number for a
+// purpose. Only the bcrypt hash of the code is stored.
+type OTP struct {
+	ID         int64      `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Purpose    string     `gorm:"size:20;index:idx_otp_target;not null" json:"purpose"`
+	Channel    string     `gorm:"size:10;index:idx_otp_target;not null" json:"channel"`
+	Target     string     `gorm:"size:150;index:idx_otp_target;not null" json:"target"`
+	Code       string     `gorm:"size:100;not null" json:"-"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiredAt  time.Time  `json:"expired_at"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (model *OTP) TableName() string {
+	return os.Getenv("DB_PREFIX") + OTPTableName
+}
+
+func (model *OTP) IsExpired() bool {
+	return model.ExpiredAt.Before(time.Now())
+}
+
+type RequestOTPRequest struct {
+	Purpose string `json:"purpose" validate:"required,oneof=login registration" name:"purpose"`
+	Channel string `json:"channel" validate:"required,oneof=email sms" name:"channel"`
+	Target  string `json:"target" validate:"required,max=150" name:"target"`
+}
+
+type VerifyOTPRequest struct {
+	Purpose string `json:"purpose" validate:"required,oneof=login registration" name:"purpose"`
+	Channel string `json:"channel" validate:"required,oneof=email sms" name:"channel"`
+	Target  string `json:"target" validate:"required,max=150" name:"target"`
+	Code    string `json:"code" validate:"required,numeric,len=6" name:"code"`
+}
+
+type RequestOTPResponse struct {
+	ExpiredAt time.Time `json:"expired_at"`
+	ResendAt  time.Time `json:"resend_at"`
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/otp/domain"
+	OTPService "boiler-plate/internal/otp/service"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+)
+
+type HTTPHandler struct {
+	App        *handler.BaseHTTPHandler
+	OTPService OTPService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, otpService OTPService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:        handler,
+		OTPService: otpService,
+	}
+}
+
+func (h HTTPHandler) Request(ctx *app.Context) *server.ResponseInterface {
+	var request domain.RequestOTPRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.OTPService.Request(ctx, &request)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) Verify(ctx *app.Context) *server.ResponseInterface {
+	var request domain.VerifyOTPRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.OTPService.Verify(ctx, &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"boiler-plate/internal/otp/domain"
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, model *domain.OTP) error
+	FindLatest(ctx context.Context, purpose, channel, target string) (*domain.OTP, error)
+	IncrementAttempts(ctx context.Context, id int64, maxAttempts int) (bool, error)
+	MarkVerified(ctx context.Context, id int64, verifiedAt time.Time) (bool, error)
+}
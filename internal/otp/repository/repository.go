@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"boiler-plate/internal/otp/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db   *gorm.DB
+	base *baseModel.SQLClientRepository
+}
+
+func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
+	return &Repo{db: db, base: base}
+}
+
+// Create stores a new code. Codes still pending for the same purpose, channel
+// and target are expired so only the latest one can be verified.
+func (r Repo) Create(ctx context.Context, model *domain.OTP) error {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.OTP{}).
+			Where("purpose = ? AND channel = ? AND target = ? AND verified_at IS NULL AND expired_at > ?",
+				model.Purpose, model.Channel, model.Target, time.Now()).
+			Update("expired_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(model).Error
+	}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// FindLatest returns the last code issued for purpose, channel and target,
+// verified or not.
+func (r Repo) FindLatest(ctx context.Context, purpose, channel, target string) (*domain.OTP, error) {
+	var (
+		models *domain.OTP
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.OTP{}).
+		Where("purpose = ? AND channel = ? AND target = ?", purpose, channel, target).
+		Order("id desc").
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+// IncrementAttempts takes one attempt of a pending code. It reports false when
+// the code was verified meanwhile or maxAttempts are used up, the check and
+// the increment are one statement so parallel guesses cannot go past it.
+func (r Repo) IncrementAttempts(ctx context.Context, id int64, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.OTP{}).
+		Where("id = ? AND attempts < ? AND verified_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, errs.Wrap(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkVerified reports false when the code was already verified, so a code
+// is accepted by one request only.
+func (r Repo) MarkVerified(ctx context.Context, id int64, verifiedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.OTP{}).
+		Where("id = ? AND verified_at IS NULL", id).
+		Update("verified_at", verifiedAt)
+	if result.Error != nil {
+		return false, errs.Wrap(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"boiler-plate/internal/otp/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+)
+
+type Service interface {
+	Request(ctx context.Context, req *domain.RequestOTPRequest) (*domain.RequestOTPResponse, *exception.Exception)
+	Send(ctx context.Context, purpose, channel, target string, params map[string]string) (*domain.RequestOTPResponse, *exception.Exception)
+	Verify(ctx context.Context, req *domain.VerifyOTPRequest) *exception.Exception
+}
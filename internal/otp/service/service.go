@@ -0,0 +1,251 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	accountDomain "boiler-plate/internal/account/domain"
+	accountRepository "boiler-plate/internal/account/repository"
+	"boiler-plate/internal/otp/domain"
+	"boiler-plate/internal/otp/repository"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/notification"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/utils/rand"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"time"
+)
+
+const codeLength = 6
+
+// NewService creates new otp service
+func NewService(
+	config *appconf.Config, repo repository.Repository, accountRepo accountRepository.Repository,
+	validate *xvalidator.Validator, notifier *notification.Client,
+) Service {
+	return &service{
+		config:      config,
+		otpRepo:     repo,
+		accountRepo: accountRepo,
+		validate:    validate,
+		notifier:    notifier,
+	}
+}
+
+type service struct {
+	config      *appconf.Config
+	otpRepo     repository.Repository
+	accountRepo accountRepository.Repository
+	validate    *xvalidator.Validator
+	notifier    *notification.Client
+}
+
+func (s service) Request(ctx context.Context, req *domain.RequestOTPRequest) (*domain.RequestOTPResponse, *exception.Exception) {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+	if exc := s.validateTarget(req.Channel, req.Target); exc != nil {
+		return nil, exc
+	}
+	req.Target = normalizeTarget(req.Channel, req.Target)
+
+	// Guest requests only reach accounts that can use the code. Any other
+	// target gets a code that is never sent, so the cooldown and the answer
+	// are the same whether an account owns the target or not.
+	eligible, err := s.isEligible(ctx, req.Purpose, req.Channel, req.Target)
+	if err != nil {
+		return nil, exception.Internal("failed to find account", err)
+	}
+	if !eligible {
+		model, _, exc := s.issue(ctx, req.Purpose, req.Channel, req.Target)
+		if exc != nil {
+			return nil, exc
+		}
+		return s.response(model), nil
+	}
+	return s.Send(ctx, req.Purpose, req.Channel, req.Target, nil)
+}
+
+// isEligible reports whether an account owns target and can use a code for
+// purpose: login codes go to verified addresses, registration codes to the
+// ones still waiting for verification.
+func (s service) isEligible(ctx context.Context, purpose, channel, target string) (bool, error) {
+	var (
+		account *accountDomain.Account
+		err     error
+	)
+	if channel == domain.ChannelSMS {
+		account, err = s.accountRepo.FindByPhone(ctx, target)
+	} else {
+		account, err = s.accountRepo.FindByEmail(ctx, target)
+	}
+	if err != nil || account == nil {
+		return false, err
+	}
+
+	verifiedAt := account.EmailVerifiedAt
+	if channel == domain.ChannelSMS {
+		verifiedAt = account.PhoneVerifiedAt
+	}
+	if purpose == domain.PurposeRegistration {
+		return verifiedAt == nil, nil
+	}
+	return verifiedAt != nil, nil
+}
+
+// Send issues a new code for purpose and delivers it to target through the
+// notification service. params are passed to the notification template
+// together with the code.
+func (s service) Send(
+	ctx context.Context, purpose, channel, target string, params map[string]string,
+) (*domain.RequestOTPResponse, *exception.Exception) {
+	model, code, exc := s.issue(ctx, purpose, channel, target)
+	if exc != nil {
+		return nil, exc
+	}
+	if err := s.deliver(ctx, purpose, channel, target, code, params); err != nil {
+		return nil, exception.Internal("failed to send otp", err)
+	}
+	return s.response(model), nil
+}
+
+// issue stores a new code for purpose and target unless the last one is
+// still within the resend cooldown, and returns it with the plain code.
+func (s service) issue(ctx context.Context, purpose, channel, target string) (*domain.OTP, string, *exception.Exception) {
+	now := time.Now()
+	latest, err := s.otpRepo.FindLatest(ctx, purpose, channel, target)
+	if err != nil {
+		return nil, "", exception.Internal("failed to find otp", err)
+	}
+	if latest != nil && latest.VerifiedAt == nil && now.Before(latest.CreatedAt.Add(s.config.OTPConfig.OTPResendCooldown)) {
+		return nil, "", exception.ResourceExhausted("please wait before requesting a new otp")
+	}
+
+	code, err := rand.GenerateNumeric(codeLength)
+	if err != nil {
+		return nil, "", exception.Internal("failed to generate otp", err)
+	}
+	model := &domain.OTP{
+		Purpose:   purpose,
+		Channel:   channel,
+		Target:    target,
+		Code:      password.HashPassword(code),
+		ExpiredAt: now.Add(s.expiry(purpose, channel)),
+	}
+	if err := s.otpRepo.Create(ctx, model); err != nil {
+		return nil, "", exception.Internal("failed to store otp", err)
+	}
+	return model, code, nil
+}
+
+func (s service) response(model *domain.OTP) *domain.RequestOTPResponse {
+	return &domain.RequestOTPResponse{
+		ExpiredAt: model.ExpiredAt,
+		ResendAt:  model.CreatedAt.Add(s.config.OTPConfig.OTPResendCooldown),
+	}
+}
+
+func (s service) Verify(ctx context.Context, req *domain.VerifyOTPRequest) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+
+	model, err := s.otpRepo.FindLatest(ctx, req.Purpose, req.Channel, normalizeTarget(req.Channel, req.Target))
+	if err != nil {
+		return exception.Internal("failed to find otp", err)
+	}
+	if model == nil || model.VerifiedAt != nil {
+		return exception.InvalidArgument("invalid otp")
+	}
+	if model.IsExpired() {
+		return exception.InvalidArgument("otp expired")
+	}
+	if model.Attempts >= s.config.OTPConfig.OTPMaxAttempts {
+		return exception.ResourceExhausted("too many invalid attempts, please request a new otp")
+	}
+
+	// every guess takes an attempt before the code is compared
+	claimed, err := s.otpRepo.IncrementAttempts(ctx, model.ID, s.config.OTPConfig.OTPMaxAttempts)
+	if err != nil {
+		return exception.Internal("failed to update otp", err)
+	}
+	if !claimed {
+		return exception.ResourceExhausted("too many invalid attempts, please request a new otp")
+	}
+	if !password.CheckPassword(model.Code, req.Code) {
+		return exception.InvalidArgument("invalid otp")
+	}
+
+	verified, err := s.otpRepo.MarkVerified(ctx, model.ID, time.Now())
+	if err != nil {
+		return exception.Internal("failed to update otp", err)
+	}
+	if !verified {
+		return exception.InvalidArgument("invalid otp")
+	}
+	return nil
+}
+
+func (s service) validateTarget(channel, target string) *exception.Exception {
+	tag := "email"
+	if channel == domain.ChannelSMS {
+		tag = "phone"
+	}
+	if errMap := s.validate.Var(target, tag); errMap != nil {
+		return exception.InvalidArgument("invalid target for channel " + channel)
+	}
+	return nil
+}
+
+// normalizeTarget stores email targets like the account emails, a code sent
+// to "A@x.com" is verified with "a@x.com".
+func normalizeTarget(channel, target string) string {
+	if channel == domain.ChannelEmail {
+		return accountDomain.NormalizeEmail(target)
+	}
+	return target
+}
+
+// expiry returns how long a code stays valid. Registration codes share one
+// expiry, login codes have one per channel.
+func (s service) expiry(purpose, channel string) time.Duration {
+	if purpose == domain.PurposeRegistration {
+		return s.config.OTPConfig.OTPRegisExpired
+	}
+	if channel == domain.ChannelSMS {
+		return s.config.OTPConfig.PhoneOTPExpired
+	}
+	return s.config.OTPConfig.EmailOTPExpired
+}
+
+func (s service) template(purpose, channel string) string {
+	switch {
+	case purpose == domain.PurposeRegistration && channel == domain.ChannelSMS:
+		return s.config.OTPConfig.TemplateSMSOTPRegis
+	case purpose == domain.PurposeRegistration:
+		return s.config.OTPConfig.TemplateEmailOTPRegis
+	case channel == domain.ChannelSMS:
+		return s.config.NotificationServiceConfig.TemplateSMSOTP
+	default:
+		return s.config.NotificationServiceConfig.TemplateEmailOTP
+	}
+}
+
+func (s service) deliver(ctx context.Context, purpose, channel, target, code string, params map[string]string) error {
+	templateParams := map[string]string{"otp": code}
+	for key, value := range params {
+		templateParams[key] = value
+	}
+	message := notification.Message{
+		To:         target,
+		TemplateID: s.template(purpose, channel),
+		Params:     templateParams,
+	}
+
+	var err error
+	if channel == domain.ChannelSMS {
+		_, err = s.notifier.SendSMS(ctx, notification.SMS(message))
+	} else {
+		_, err = s.notifier.SendEmail(ctx, notification.Email(message))
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+package domain
+
+type RegisterRequest struct {
+	Fullname             string `json:"fullname" validate:"required,max=150" name:"fullname"`
+	Email                string `json:"email" validate:"required,email,max=150" name:"email"`
+	Phone                string `json:"phone" validate:"required,phone" name:"phone"`
+	Password             string `json:"password" validate:"required" name:"password"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password" name:"password_confirmation"`
+	Captcha              string `json:"captcha" validate:"required" name:"captcha"`
+	Key                  string `json:"key" validate:"required" name:"key"`
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/registration/domain"
+	RegistrationService "boiler-plate/internal/registration/service"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+)
+
+type HTTPHandler struct {
+	App                 *handler.BaseHTTPHandler
+	RegistrationService RegistrationService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, registrationService RegistrationService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:                 handler,
+		RegistrationService: registrationService,
+	}
+}
+
+func (h HTTPHandler) Register(ctx *app.Context) *server.ResponseInterface {
+	var request domain.RegisterRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.RegistrationService.Register(ctx, &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
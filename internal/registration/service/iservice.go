@@ -0,0 +1,11 @@
+package service
+
+import (
+	"boiler-plate/internal/registration/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+)
+
+type Service interface {
+	Register(ctx context.Context, req *domain.RegisterRequest) *exception.Exception
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	accountDomain "boiler-plate/internal/account/domain"
+	accountRepository "boiler-plate/internal/account/repository"
+	"boiler-plate/internal/registration/domain"
+	verificationService "boiler-plate/internal/verification/service"
+	"boiler-plate/pkg/captcha"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewService creates new registration service
+func NewService(
+	config *appconf.Config, accountRepo accountRepository.Repository, verification verificationService.Service,
+	validate *xvalidator.Validator, passwordEngine *password.Engine, captcha *captcha.Captcha,
+) Service {
+	return &service{
+		config:         config,
+		accountRepo:    accountRepo,
+		verification:   verification,
+		validate:       validate,
+		passwordEngine: passwordEngine,
+		captcha:        captcha,
+	}
+}
+
+type service struct {
+	config         *appconf.Config
+	accountRepo    accountRepository.Repository
+	verification   verificationService.Service
+	validate       *xvalidator.Validator
+	passwordEngine *password.Engine
+	captcha        *captcha.Captcha
+}
+
+func (s service) Register(ctx context.Context, req *domain.RegisterRequest) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+	if !s.captcha.Verify(req.Key, req.Captcha) {
+		return exception.InvalidArgument("invalid captcha")
+	}
+
+	existing, err := s.accountRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return exception.Internal("failed to find account", err)
+	}
+	if existing != nil {
+		return exception.Conflict("Email Already Exists")
+	}
+	existing, err = s.accountRepo.FindByPhone(ctx, req.Phone)
+	if err != nil {
+		return exception.Internal("failed to find account", err)
+	}
+	if existing != nil {
+		return exception.Conflict("Phone Already Exists")
+	}
+
+	if err := s.passwordEngine.Validate(ctx, req.Password, nil); err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			return exception.InvalidArgument(policyErr.Violations)
+		}
+		return exception.Internal("failed to validate password", err)
+	}
+	now := time.Now()
+	expiredAt, err := s.passwordEngine.ExpiresAt(ctx, now)
+	if err != nil {
+		return exception.Internal("failed to load password policy", err)
+	}
+
+	// Every self registered account is a customer.
+	role, err := s.accountRepo.FindRoleByName(ctx, jwt.UserRoleCustomer)
+	if err != nil {
+		return exception.Internal("failed to find role", err)
+	}
+	if role == nil {
+		return exception.Internal("default role is not seeded", errors.New(jwt.UserRoleCustomer+" not found"))
+	}
+
+	account := &accountDomain.Account{
+		Fullname:          req.Fullname,
+		Email:             req.Email,
+		Phone:             req.Phone,
+		Password:          password.HashPassword(req.Password),
+		RoleID:            role.ID,
+		PasswordChangedAt: &now,
+		PasswordExpiredAt: expiredAt,
+	}
+	if err := s.accountRepo.Create(ctx, account); err != nil {
+		// a concurrent registration took the email or phone after the checks
+		if baseModel.IsDuplicateKey(err) {
+			exc := exception.Conflict("Email or Phone Already Exists")
+			exc.Cause = err
+			return exc
+		}
+		return exception.Internal("failed to create account", err)
+	}
+
+	// The account is created either way; a failed delivery can be retried by
+	// requesting a new code.
+	for _, verificationType := range []string{accountDomain.VerificationTypeEmail, accountDomain.VerificationTypePhone} {
+		if exc := s.verification.Issue(ctx, account, verificationType); exc != nil {
+			logrus.WithError(exc.Cause).WithField("account_id", account.ID).
+				Errorf("failed to issue %s verification: %v", verificationType, exc.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// SettingsEventKey keys every settings event so consumers see them in
+// revision order.
+const SettingsEventKey = "settings"
+
+// SettingsChangedEvent is published after every committed settings revision.
+type SettingsChangedEvent struct {
+	Revision       int           `json:"revision"`
+	Action         string        `json:"action"`
+	RolledBackFrom *int          `json:"rolled_back_from,omitempty"`
+	ChangedBy      string        `json:"changed_by"`
+	ChangedAt      time.Time     `json:"changed_at"`
+	Changes        []FieldChange `json:"changes"`
+	Settings       *MainTable    `json:"settings"`
+}
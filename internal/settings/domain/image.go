@@ -0,0 +1,42 @@
+package domain
+
+const (
+	ImageLogo    = "logo"
+	ImageFavicon = "favicon"
+)
+
+// ImageRequest is the json body of a base64 image upload.
+type ImageRequest struct {
+	Image string `json:"image" binding:"required"`
+}
+
+// IsImageKind reports whether kind is an image stored in the settings row.
+func IsImageKind(kind string) bool {
+	return kind == ImageLogo || kind == ImageFavicon
+}
+
+// Image returns the filename stored for kind.
+func (model *MainTable) Image(kind string) string {
+	switch kind {
+	case ImageLogo:
+		return model.LogoImage
+	case ImageFavicon:
+		return model.Favicon
+	}
+	return ""
+}
+
+// SetImage replaces the filename stored for kind.
+func (model *MainTable) SetImage(kind, filename string) {
+	switch kind {
+	case ImageLogo:
+		model.LogoImage = filename
+	case ImageFavicon:
+		model.Favicon = filename
+	}
+}
+
+// IsImageReferenced reports whether filename is still used by any image field.
+func (model *MainTable) IsImageReferenced(filename string) bool {
+	return model.LogoImage == filename || model.Favicon == filename
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"boiler-plate/pkg/password"
 	"os"
 	"time"
 )
@@ -15,13 +16,13 @@ type MainTable struct {
 	TaxFee                    float64    `json:"tax_fee,omitempty"`
 	ReminderTaxProfileExpired int        `json:"reminder_tax_profile_expired,omitempty"`
 	ValidAccountExpired       int        `json:"valid_account_expired,omitempty"`
-	AccountExpiredPeriod      string     `json:"account_expired_period,omitempty" gorm:"default:days" validate:"eq=days|eq=months|eq=years"`
+	AccountExpiredPeriod      string     `json:"account_expired_period,omitempty" gorm:"default:days" validate:"eq=days|eq=months|eq=years" name:"account_expired_period"`
 	LogoImage                 string     `json:"logo_image,omitempty"`
 	Favicon                   string     `json:"favicon,omitempty"`
 	PasswordLength            int        `json:"password_length,omitempty"`
 	PasswordInvalid           int        `json:"password_invalid,omitempty" gorm:"default:3"`
 	PasswordExpirationCount   int        `json:"password_expiration_count,omitempty"`
-	PasswordExpiredPeriod     string     `json:"password_expired_period,omitempty" gorm:"default:days" validate:"eq=days|eq=months|eq=years"`
+	PasswordExpiredPeriod     string     `json:"password_expired_period,omitempty" gorm:"default:days" validate:"eq=days|eq=months|eq=years" name:"password_expired_period"`
 	ExpirationReminderDay     int        `json:"expiration_reminder_day,omitempty"`
 	PasswordCycle             int        `json:"password_cycle,omitempty"`
 	ComplexityNumeric         bool       `json:"complexity_numeric" gorm:"default:false"`
@@ -34,3 +35,18 @@ type MainTable struct {
 func (model *MainTable) TableName() string {
 	return os.Getenv("DB_PREFIX") + SettingsTableName
 }
+
+// PasswordPolicy maps the password columns to the policy enforced by pkg/password.
+func (model *MainTable) PasswordPolicy() *password.Policy {
+	return &password.Policy{
+		MinLength:        model.PasswordLength,
+		RequireNumeric:   model.ComplexityNumeric,
+		RequireAlphabet:  model.ComplexityAlphabet,
+		RequireUppercase: model.ComplexityUppercase,
+		RequireSymbol:    model.ComplexitySymbol,
+		History:          model.PasswordCycle,
+		ExpirationCount:  model.PasswordExpirationCount,
+		ExpiredPeriod:    model.PasswordExpiredPeriod,
+		MaxInvalid:       model.PasswordInvalid,
+	}
+}
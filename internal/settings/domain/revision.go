@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SettingsRevisionTableName = "settings_revisions"
+
+	RevisionActionInitial  = "initial"
+	RevisionActionUpdate   = "update"
+	RevisionActionRollback = "rollback"
+)
+
+// SettingsRevision is an immutable snapshot of the settings row taken every
+// time it changes.
+type SettingsRevision struct {
+	ID             int       `gorm:"primaryKey;not null;autoIncrement" json:"-"`
+	Revision       int       `gorm:"uniqueIndex;not null" json:"revision"`
+	Action         string    `gorm:"size:20;not null" json:"action"`
+	RolledBackFrom *int      `json:"rolled_back_from,omitempty"`
+	ChangedBy      string    `gorm:"size:100" json:"changed_by"`
+	ChangedAt      time.Time `json:"changed_at"`
+	Snapshot       string    `gorm:"type:text" json:"-"`
+	Diff           string    `gorm:"type:text" json:"-"`
+}
+
+func (model *SettingsRevision) TableName() string {
+	return os.Getenv("DB_PREFIX") + SettingsRevisionTableName
+}
+
+// GetSnapshot decodes the settings stored in the revision.
+func (model *SettingsRevision) GetSnapshot() (*MainTable, error) {
+	var settings MainTable
+	if err := json.Unmarshal([]byte(model.Snapshot), &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// GetDiff decodes the field changes stored in the revision.
+func (model *SettingsRevision) GetDiff() ([]FieldChange, error) {
+	changes := make([]FieldChange, 0)
+	if model.Diff == "" {
+		return changes, nil
+	}
+	if err := json.Unmarshal([]byte(model.Diff), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// FieldChange is a single field difference between two settings snapshots,
+// keyed by the json name of the field.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// RevisionDetail is the API view of a revision.
+type RevisionDetail struct {
+	*SettingsRevision
+	Settings *MainTable    `json:"settings,omitempty"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+// RevisionDiff is the API view of the difference between two revisions.
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffSettings returns the fields whose value differs between old and new.
+// id and updated_at are bookkeeping and never reported.
+func DiffSettings(old, new *MainTable) []FieldChange {
+	oldMap := settingsMap(old)
+	newMap := settingsMap(new)
+
+	fields := make([]string, 0, len(newMap))
+	for field := range newMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	changes := make([]FieldChange, 0)
+	for _, field := range fields {
+		if !reflect.DeepEqual(oldMap[field], newMap[field]) {
+			changes = append(changes, FieldChange{
+				Field: field,
+				Old:   oldMap[field],
+				New:   newMap[field],
+			})
+		}
+	}
+	return changes
+}
+
+func settingsMap(settings *MainTable) map[string]interface{} {
+	result := make(map[string]interface{})
+	if settings == nil {
+		settings = &MainTable{}
+	}
+
+	t := reflect.TypeOf(*settings)
+	v := reflect.ValueOf(*settings)
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if name == "id" || name == "updated_at" {
+			continue
+		}
+		result[name] = v.Field(i).Interface()
+	}
+	return result
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/settings/domain"
+	SettingsService "boiler-plate/internal/settings/service"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	settingsv1 "boiler-plate/pkg/pb/settings/v1"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCHandler serves SettingsService.Service as settings.v1.SettingsService.
+type GRPCHandler struct {
+	settingsv1.UnimplementedSettingsServiceServer
+	SettingsService SettingsService.Service
+}
+
+func NewGRPCHandler(settingsService SettingsService.Service) *GRPCHandler {
+	return &GRPCHandler{SettingsService: settingsService}
+}
+
+func (h GRPCHandler) FindSettings(
+	ctx context.Context, req *settingsv1.FindSettingsRequest,
+) (*settingsv1.FindSettingsResponse, error) {
+	result, exc := h.SettingsService.FindSettings(ctx)
+	if exc != nil {
+		return nil, exceptionError(ctx, "Error in finding settings", exc)
+	}
+	return &settingsv1.FindSettingsResponse{Settings: settingsToProto(result)}, nil
+}
+
+func (h GRPCHandler) UpdateSettings(
+	ctx context.Context, req *settingsv1.UpdateSettingsRequest,
+) (*settingsv1.UpdateSettingsResponse, error) {
+	if req.GetSettings() == nil {
+		return nil, status.Error(codes.InvalidArgument, "settings is required")
+	}
+
+	result, exc := h.SettingsService.UpdateSettings(ctx, settingsFromProto(req.GetSettings()), app.SubjectFromContext(ctx))
+	if exc != nil {
+		return nil, exceptionError(ctx, "Error in updating settings", exc)
+	}
+	return &settingsv1.UpdateSettingsResponse{Settings: settingsToProto(result)}, nil
+}
+
+func (h GRPCHandler) ListRevisions(
+	ctx context.Context, req *settingsv1.ListRevisionsRequest,
+) (*settingsv1.ListRevisionsResponse, error) {
+	limit, page := int(req.GetLimit()), int(req.GetPage())
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	paginate := baseModel.NewPaginate(limit, page, nil)
+
+	result, exc := h.SettingsService.FindRevisions(ctx, paginate)
+	if exc != nil {
+		return nil, exceptionError(ctx, "Error in finding settings revisions", exc)
+	}
+
+	revisions := make([]*settingsv1.Revision, 0, len(result))
+	for i := range result {
+		revision, err := revisionToProto(&result[i])
+		if err != nil {
+			return nil, internalError(ctx, "Error in reading settings revision", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return &settingsv1.ListRevisionsResponse{
+		Revisions: revisions,
+		Pagination: &settingsv1.Pagination{
+			Page:       int32(paginate.Page),
+			Limit:      int32(paginate.Limit),
+			TotalRows:  int32(paginate.TotalRows),
+			TotalPages: int32(paginate.TotalPages),
+		},
+	}, nil
+}
+
+// exceptionError maps exc to its gRPC status. Internal errors are logged and
+// answered with message only.
+func exceptionError(ctx context.Context, message string, exc *exception.Exception) error {
+	switch exc.Code {
+	case exception.InvalidArgumentCode:
+		if exc.Fields != nil {
+			return validationError(exc.Fields)
+		}
+		return status.Error(codes.InvalidArgument, exc.MessageString())
+	case exception.NotFoundCode:
+		return status.Error(codes.NotFound, exc.MessageString())
+	case exception.AlreadyExistsCode:
+		// a concurrent settings update, the client retries
+		return status.Error(codes.Aborted, exc.MessageString())
+	case exception.PermissionDeniedCode:
+		return status.Error(codes.PermissionDenied, exc.MessageString())
+	case exception.UnauthenticatedCode:
+		return status.Error(codes.Unauthenticated, exc.MessageString())
+	case exception.ResourceExhaustedCode:
+		return status.Error(codes.ResourceExhausted, exc.MessageString())
+	default:
+		return internalError(ctx, message, exc)
+	}
+}
+
+func internalError(ctx context.Context, message string, err error) error {
+	logrus.Errorf("REQUEST ID: %s , message: %s , error: %v", app.RequestIDFromContext(ctx), message, err)
+	return status.Error(codes.Internal, message)
+}
+
+// validationError returns the xvalidator errors as BadRequest field
+// violations.
+func validationError(errMap map[string]string) error {
+	fields := make([]string, 0, len(errMap))
+	for field := range errMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: errMap[field],
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "Error in validating settings").WithDetails(badRequest)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "Error in validating settings")
+	}
+	return st.Err()
+}
+
+func settingsToProto(model *domain.MainTable) *settingsv1.Settings {
+	if model == nil {
+		return nil
+	}
+	settings := &settingsv1.Settings{
+		Id:                        int32(model.ID),
+		Currency:                  model.Currency,
+		TaxFee:                    model.TaxFee,
+		ReminderTaxProfileExpired: int32(model.ReminderTaxProfileExpired),
+		ValidAccountExpired:       int32(model.ValidAccountExpired),
+		AccountExpiredPeriod:      model.AccountExpiredPeriod,
+		LogoImage:                 model.LogoImage,
+		Favicon:                   model.Favicon,
+		PasswordLength:            int32(model.PasswordLength),
+		PasswordInvalid:           int32(model.PasswordInvalid),
+		PasswordExpirationCount:   int32(model.PasswordExpirationCount),
+		PasswordExpiredPeriod:     model.PasswordExpiredPeriod,
+		ExpirationReminderDay:     int32(model.ExpirationReminderDay),
+		PasswordCycle:             int32(model.PasswordCycle),
+		ComplexityNumeric:         model.ComplexityNumeric,
+		ComplexityAlphabet:        model.ComplexityAlphabet,
+		ComplexityUppercase:       model.ComplexityUppercase,
+		ComplexitySymbol:          model.ComplexitySymbol,
+	}
+	if model.UpdatedAt != nil {
+		settings.UpdatedAt = timestamppb.New(*model.UpdatedAt)
+	}
+	return settings
+}
+
+func settingsFromProto(settings *settingsv1.Settings) *domain.MainTable {
+	return &domain.MainTable{
+		Currency:                  settings.GetCurrency(),
+		TaxFee:                    settings.GetTaxFee(),
+		ReminderTaxProfileExpired: int(settings.GetReminderTaxProfileExpired()),
+		ValidAccountExpired:       int(settings.GetValidAccountExpired()),
+		AccountExpiredPeriod:      settings.GetAccountExpiredPeriod(),
+		LogoImage:                 settings.GetLogoImage(),
+		Favicon:                   settings.GetFavicon(),
+		PasswordLength:            int(settings.GetPasswordLength()),
+		PasswordInvalid:           int(settings.GetPasswordInvalid()),
+		PasswordExpirationCount:   int(settings.GetPasswordExpirationCount()),
+		PasswordExpiredPeriod:     settings.GetPasswordExpiredPeriod(),
+		ExpirationReminderDay:     int(settings.GetExpirationReminderDay()),
+		PasswordCycle:             int(settings.GetPasswordCycle()),
+		ComplexityNumeric:         settings.GetComplexityNumeric(),
+		ComplexityAlphabet:        settings.GetComplexityAlphabet(),
+		ComplexityUppercase:       settings.GetComplexityUppercase(),
+		ComplexitySymbol:          settings.GetComplexitySymbol(),
+	}
+}
+
+func revisionToProto(model *domain.SettingsRevision) (*settingsv1.Revision, error) {
+	changes, err := model.GetDiff()
+	if err != nil {
+		return nil, err
+	}
+	revision := &settingsv1.Revision{
+		Revision:  int32(model.Revision),
+		Action:    model.Action,
+		ChangedBy: model.ChangedBy,
+		ChangedAt: timestamppb.New(model.ChangedAt),
+	}
+	if model.RolledBackFrom != nil {
+		rolledBackFrom := int32(*model.RolledBackFrom)
+		revision.RolledBackFrom = &rolledBackFrom
+	}
+	for _, change := range changes {
+		oldValue, err := json.Marshal(change.Old)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := json.Marshal(change.New)
+		if err != nil {
+			return nil, err
+		}
+		revision.Changes = append(revision.Changes, &settingsv1.FieldChange{
+			Field:    change.Field,
+			OldValue: string(oldValue),
+			NewValue: string(newValue),
+		})
+	}
+	return revision, nil
+}
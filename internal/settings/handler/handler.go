@@ -2,13 +2,19 @@ package handler
 
 import (
 	"boiler-plate/internal/base/app"
-	BaseDomain "boiler-plate/internal/base/domain"
 	"boiler-plate/internal/base/handler"
 	"boiler-plate/internal/settings/domain"
 	SettingsService "boiler-plate/internal/settings/service"
-	"boiler-plate/pkg/responsehelper"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
 	"boiler-plate/pkg/server"
+	"encoding/base64"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 )
 
 type HTTPHandler struct {
@@ -25,45 +31,161 @@ func NewHTTPHandler(
 	}
 }
 
-// AsJson always return httpStatus: 200, but Status field: 500,400,200...
-func (h HTTPHandler) AsJson(ctx *app.Context, status int, message string, data interface{}) *server.Response {
-	return h.App.AsJson(ctx, status, message, data)
+func (h HTTPHandler) FindSettings(ctx *app.Context) *server.ResponseInterface {
+	result, exc := h.SettingsService.FindSettings(ctx)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
 }
 
-func (h HTTPHandler) AsJsonInterface(ctx *app.Context, status int, data interface{}) *server.ResponseInterface {
-	return h.App.AsJsonInterface(ctx, status, data)
+func (h HTTPHandler) UpdateSettings(ctx *app.Context) *server.ResponseInterface {
+	var request domain.MainTable
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.SettingsService.UpdateSettings(ctx, &request, ctx.Subject())
+	return h.settingsResult(ctx, result, exc)
 }
 
-// BadRequest For mobile, httpStatus:200, but Status field: http.MobileBadRequest
-func (h HTTPHandler) BadRequest(ctx *app.Context, err error) *server.Response {
-	return h.App.AsJson(ctx, http.StatusBadRequest, err.Error(), nil)
+func (h HTTPHandler) PatchSettings(ctx *app.Context) *server.ResponseInterface {
+	var request map[string]interface{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	result, exc := h.SettingsService.PatchSettings(ctx, request, ctx.Subject())
+	return h.settingsResult(ctx, result, exc)
 }
 
-// ForbiddenRequest For mobile, httpStatus:200, but Status field: http.StatusForbidden
-func (h HTTPHandler) ForbiddenRequest(ctx *app.Context, err error) *server.Response {
-	return h.App.AsJson(ctx, http.StatusForbidden, err.Error(), nil)
+func (h HTTPHandler) settingsResult(
+	ctx *app.Context, result *domain.MainTable, exc *exception.Exception,
+) *server.ResponseInterface {
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
 }
 
-func (h HTTPHandler) AsError(ctx *app.Context, message string) *server.Response {
-	return h.App.AsJson(ctx, http.StatusInternalServerError, message, nil)
+func (h HTTPHandler) FindRevisions(ctx *app.Context) *server.ResponseInterface {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	paginate := baseModel.NewPaginate(limit, page, nil)
+
+	result, exc := h.SettingsService.FindRevisions(ctx, paginate)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+
+	return h.App.AsPage(ctx, http.StatusOK, handler.MessageSuccess, result, paginate)
 }
 
-func (h HTTPHandler) ThrowBadRequestException(ctx *app.Context, message string) *server.Response {
-	return h.App.ThrowExceptionJson(ctx, http.StatusBadRequest, 0, "Bad Request", message)
+func (h HTTPHandler) FindRevision(ctx *app.Context) *server.ResponseInterface {
+	revision, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument("revision must be a number"))
+	}
+
+	result, exc := h.SettingsService.FindRevision(ctx, revision)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
 }
 
-func (h HTTPHandler) FindSettings(ctx *app.Context) *server.ResponseInterface {
-	result, err := h.SettingsService.FindSettings(ctx)
+func (h HTTPHandler) DiffRevisions(ctx *app.Context) *server.ResponseInterface {
+	from, errFrom := strconv.Atoi(ctx.Query("from"))
+	to, errTo := strconv.Atoi(ctx.Query("to"))
+	if errFrom != nil || errTo != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument("from and to must be revision numbers"))
+	}
+
+	result, exc := h.SettingsService.DiffRevisions(ctx, from, to)
+	if exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, result)
+}
+
+func (h HTTPHandler) RollbackSettings(ctx *app.Context) *server.ResponseInterface {
+	revision, err := strconv.Atoi(ctx.Param("revision"))
 	if err != nil {
-		respStatus := responsehelper.GetStatusResponse(http.StatusBadRequest, "Error in finding settings")
-		return h.AsJsonInterface(ctx, http.StatusBadRequest, respStatus)
+		return h.App.AsException(ctx, exception.InvalidArgument("revision must be a number"))
+	}
+
+	result, exc := h.SettingsService.RollbackSettings(ctx, revision, ctx.Subject())
+	return h.settingsResult(ctx, result, exc)
+}
+
+// UploadImage stores a multipart "file" upload as the logo or favicon.
+func (h HTTPHandler) UploadImage(kind string) handler.HandlerFnInterface {
+	return func(ctx *app.Context) *server.ResponseInterface {
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			return h.App.AsException(ctx, exception.InvalidArgument("The file field is required."))
+		}
+		if fileHeader.Size > int64(h.App.AppConfig.AppEnvConfig.FileMaxSize) {
+			return h.App.AsException(ctx, exception.InvalidArgument("file size exceeds the maximum allowed size"))
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+		}
+
+		data := base64.StdEncoding.EncodeToString(content)
+		result, exc := h.SettingsService.UploadImage(ctx, kind, data, ctx.Subject())
+		return h.settingsResult(ctx, result, exc)
 	}
+}
 
-	respStatus := responsehelper.GetStatusResponse(http.StatusOK, "")
+// UploadImageBase64 stores a base64 json upload as the logo or favicon.
+// Data URIs ("data:image/png;base64,...") are accepted as well.
+func (h HTTPHandler) UploadImageBase64(kind string) handler.HandlerFnInterface {
+	return func(ctx *app.Context) *server.ResponseInterface {
+		var request domain.ImageRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+		}
+		if strings.HasPrefix(request.Image, "data:") {
+			_, request.Image, _ = strings.Cut(request.Image, ",")
+		}
 
-	finalResponse := struct {
-		*BaseDomain.Status
-		*domain.MainTable
-	}{respStatus, result}
-	return h.AsJsonInterface(ctx, http.StatusOK, finalResponse)
+		result, exc := h.SettingsService.UploadImage(ctx, kind, request.Image, ctx.Subject())
+		return h.settingsResult(ctx, result, exc)
+	}
+}
+
+// ServeImage writes the logo or favicon file. The stored filename is unique
+// per upload so it doubles as the ETag.
+func (h HTTPHandler) ServeImage(kind string) handler.HandlerFnInterface {
+	return func(ctx *app.Context) *server.ResponseInterface {
+		reader, object, exc := h.SettingsService.OpenImage(ctx, kind)
+		if exc != nil {
+			return h.App.AsException(ctx, exc)
+		}
+		defer reader.Close()
+
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.Header("ETag", fmt.Sprintf("%q", path.Base(object.Key)))
+		if object.ContentType != "" {
+			ctx.Header("Content-Type", object.ContentType)
+		}
+		if seeker, ok := reader.(io.ReadSeeker); ok {
+			http.ServeContent(ctx.Writer, ctx.Request, object.Key, object.ModTime, seeker)
+		} else {
+			ctx.DataFromReader(http.StatusOK, object.Size, object.ContentType, reader, nil)
+		}
+		return &server.ResponseInterface{Status: ctx.Writer.Status()}
+	}
 }
@@ -2,9 +2,15 @@ package repository
 
 import (
 	"boiler-plate/internal/settings/domain"
+	baseModel "boiler-plate/pkg/db"
 	"context"
 )
 
 type Repository interface {
 	FindSettings(ctx context.Context) (*domain.MainTable, error)
+	UpdateSettings(ctx context.Context, model *domain.MainTable, revision *domain.SettingsRevision) error
+	ModifySettings(ctx context.Context, modify func(model *domain.MainTable) error, revision *domain.SettingsRevision) (*domain.MainTable, error)
+	FindRevisions(ctx context.Context, paginate *baseModel.Paginate) ([]domain.SettingsRevision, error)
+	FindRevision(ctx context.Context, revision int) (*domain.SettingsRevision, error)
+	RollbackSettings(ctx context.Context, revision int, changedBy string, restore func(model *domain.MainTable) error) (*domain.MainTable, *domain.SettingsRevision, error)
 }
@@ -1,21 +1,32 @@
 package repository
 
 import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/events"
 	"boiler-plate/internal/settings/domain"
 	baseModel "boiler-plate/pkg/db"
 	"boiler-plate/pkg/errs"
+	"boiler-plate/pkg/outbox"
 	"context"
+	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Repo struct {
-	db   *gorm.DB
-	base *baseModel.SQLClientRepository
+	db     *gorm.DB
+	base   *baseModel.SQLClientRepository
+	events *outbox.Outbox
+	// eventTopic receives a SettingsChangedEvent for every revision through
+	// the outbox, empty disables it.
+	eventTopic string
 }
 
-func NewRepository(db *gorm.DB, base *baseModel.SQLClientRepository) Repository {
-	return &Repo{db: db, base: base}
+func NewRepository(
+	db *gorm.DB, base *baseModel.SQLClientRepository, events *outbox.Outbox, eventTopic string,
+) Repository {
+	return &Repo{db: db, base: base, events: events, eventTopic: eventTopic}
 }
 
 func (r Repo) FindSettings(ctx context.Context) (*domain.MainTable, error) {
@@ -33,3 +44,204 @@ func (r Repo) FindSettings(ctx context.Context) (*domain.MainTable, error) {
 	}
 	return models, nil
 }
+
+// UpdateSettings stores model and records revision in the same transaction.
+// revision only needs ChangedBy and Action, the rest is filled in here.
+func (r Repo) UpdateSettings(ctx context.Context, model *domain.MainTable, revision *domain.SettingsRevision) error {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.saveWithRevision(ctx, tx, model, revision)
+	}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// ModifySettings reads the settings row, applies modify and stores the result
+// with revision, all in one transaction. A modify error aborts the change.
+func (r Repo) ModifySettings(
+	ctx context.Context, modify func(model *domain.MainTable) error, revision *domain.SettingsRevision,
+) (*domain.MainTable, error) {
+	var (
+		models domain.MainTable
+	)
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := modify(&models); err != nil {
+			return err
+		}
+		now := time.Now()
+		models.UpdatedAt = &now
+		return r.saveWithRevision(ctx, tx, &models, revision)
+	}); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &models, nil
+}
+
+func (r Repo) FindRevisions(ctx context.Context, paginate *baseModel.Paginate) ([]domain.SettingsRevision, error) {
+	var (
+		models []domain.SettingsRevision
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.SettingsRevision{}).
+		Scopes(paginate.PaginatedResult(&domain.SettingsRevision{}, r.db.WithContext(ctx))).
+		Order("revision desc").
+		Find(&models).
+		Error; err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+func (r Repo) FindRevision(ctx context.Context, revision int) (*domain.SettingsRevision, error) {
+	var (
+		models *domain.SettingsRevision
+	)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.SettingsRevision{}).
+		Where("revision = ?", revision).
+		First(&models).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return models, nil
+}
+
+// RollbackSettings restores the settings stored in revision and records the
+// rollback as a new revision. restore may adjust the snapshot before it is
+// stored, an error aborts the rollback. It returns nil values when revision
+// does not exist.
+func (r Repo) RollbackSettings(
+	ctx context.Context, revision int, changedBy string, restore func(model *domain.MainTable) error,
+) (*domain.MainTable, *domain.SettingsRevision, error) {
+	var (
+		settings    *domain.MainTable
+		newRevision *domain.SettingsRevision
+	)
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target domain.SettingsRevision
+		if err := tx.Where("revision = ?", revision).First(&target).Error; err != nil {
+			return err
+		}
+		snapshot, err := target.GetSnapshot()
+		if err != nil {
+			return err
+		}
+
+		var current domain.MainTable
+		if err := tx.First(&current).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		snapshot.ID = current.ID
+		if err := restore(snapshot); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		snapshot.UpdatedAt = &now
+		rolledBackFrom := target.Revision
+		newRevision = &domain.SettingsRevision{
+			Action:         domain.RevisionActionRollback,
+			RolledBackFrom: &rolledBackFrom,
+			ChangedBy:      changedBy,
+		}
+		settings = snapshot
+		return r.saveWithRevision(ctx, tx, snapshot, newRevision)
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, errs.Wrap(err)
+	}
+	return settings, newRevision, nil
+}
+
+// saveWithRevision must run inside a transaction. The unique index on
+// revision makes a concurrent writer fail instead of reusing a number.
+func (r Repo) saveWithRevision(ctx context.Context, tx *gorm.DB, model *domain.MainTable, revision *domain.SettingsRevision) error {
+	var (
+		current *domain.MainTable
+		latest  domain.SettingsRevision
+	)
+	if err := tx.First(&current).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		current = nil
+	}
+	if err := tx.Order("revision desc").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+
+	// The row existed before history was recorded, keep it as revision 1
+	// so the first change can be diffed and rolled back.
+	if latest.Revision == 0 && current != nil {
+		snapshot, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		changedAt := time.Now()
+		if current.UpdatedAt != nil {
+			changedAt = *current.UpdatedAt
+		}
+		latest = domain.SettingsRevision{
+			Revision:  1,
+			Action:    domain.RevisionActionInitial,
+			ChangedBy: "system",
+			ChangedAt: changedAt,
+			Snapshot:  string(snapshot),
+		}
+		if err := tx.Create(&latest).Error; err != nil {
+			return err
+		}
+	}
+
+	snapshot, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	diff, err := json.Marshal(domain.DiffSettings(current, model))
+	if err != nil {
+		return err
+	}
+
+	revision.Revision = latest.Revision + 1
+	revision.Snapshot = string(snapshot)
+	revision.Diff = string(diff)
+	revision.ChangedAt = time.Now()
+	if model.UpdatedAt != nil {
+		revision.ChangedAt = *model.UpdatedAt
+	}
+
+	if err := tx.Save(model).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(revision).Error; err != nil {
+		return err
+	}
+
+	changes, err := revision.GetDiff()
+	if err != nil {
+		return err
+	}
+	return r.events.Enqueue(tx, app.RequestIDFromContext(ctx), &outbox.Event{
+		Topic:   r.eventTopic,
+		Key:     domain.SettingsEventKey,
+		Type:    events.SettingsChanged,
+		Version: events.SettingsChangedVersion,
+		Data: &domain.SettingsChangedEvent{
+			Revision:       revision.Revision,
+			Action:         revision.Action,
+			RolledBackFrom: revision.RolledBackFrom,
+			ChangedBy:      revision.ChangedBy,
+			ChangedAt:      revision.ChangedAt,
+			Changes:        changes,
+			Settings:       model,
+		},
+	})
+}
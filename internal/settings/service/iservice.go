@@ -2,9 +2,25 @@ package service
 
 import (
 	"boiler-plate/internal/settings/domain"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/storage"
 	"context"
+	"io"
 )
 
 type Service interface {
-	FindSettings(ctx context.Context) (*domain.MainTable, error)
+	FindSettings(ctx context.Context) (*domain.MainTable, *exception.Exception)
+	UpdateSettings(ctx context.Context, req *domain.MainTable, changedBy string) (*domain.MainTable, *exception.Exception)
+	PatchSettings(ctx context.Context, req map[string]interface{}, changedBy string) (*domain.MainTable, *exception.Exception)
+	FindRevisions(ctx context.Context, paginate *baseModel.Paginate) ([]domain.SettingsRevision, *exception.Exception)
+	FindRevision(ctx context.Context, revision int) (*domain.RevisionDetail, *exception.Exception)
+	DiffRevisions(ctx context.Context, from, to int) (*domain.RevisionDiff, *exception.Exception)
+	RollbackSettings(ctx context.Context, revision int, changedBy string) (*domain.MainTable, *exception.Exception)
+	// PasswordPolicy returns an error, not an exception, to implement
+	// password.PolicySource.
+	PasswordPolicy(ctx context.Context) (*password.Policy, error)
+	UploadImage(ctx context.Context, kind, data string, changedBy string) (*domain.MainTable, *exception.Exception)
+	OpenImage(ctx context.Context, kind string) (io.ReadCloser, *storage.Object, *exception.Exception)
 }
@@ -4,26 +4,306 @@ import (
 	"boiler-plate/app/appconf"
 	"boiler-plate/internal/settings/domain"
 	"boiler-plate/internal/settings/repository"
-	"boiler-plate/pkg/errs"
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/filevalidation"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/storage"
+	"boiler-plate/pkg/xvalidator"
+	"bytes"
 	"context"
-	"github.com/go-playground/validator/v10"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // NewService creates new user service
-func NewService(config *appconf.Config, repo repository.Repository, validate *validator.Validate) Service {
-	return &service{config: config, settingsRepo: repo, validate: validate}
+func NewService(
+	config *appconf.Config, repo repository.Repository, validate *xvalidator.Validator, store storage.Storage,
+) Service {
+	return &service{config: config, settingsRepo: repo, validate: validate, store: store}
 }
 
 type service struct {
 	config       *appconf.Config
 	settingsRepo repository.Repository
-	validate     *validator.Validate
+	validate     *xvalidator.Validator
+	store        storage.Storage
+}
+
+// notFound is returned when a revision or image does not exist.
+const notFound = "Data not found in database."
+
+func (s service) FindSettings(ctx context.Context) (*domain.MainTable, *exception.Exception) {
+	result, err := s.settingsRepo.FindSettings(ctx)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings", err)
+	}
+	return result, nil
+}
+
+// UpdateSettings replaces every editable field of the settings row with req.
+// The images are kept, they only change through UploadImage.
+func (s service) UpdateSettings(ctx context.Context, req *domain.MainTable, changedBy string) (
+	*domain.MainTable, *exception.Exception,
+) {
+	current, err := s.settingsRepo.FindSettings(ctx)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings", err)
+	}
+
+	model := *req
+	model.ID = 0
+	model.LogoImage = ""
+	model.Favicon = ""
+	if current != nil {
+		model.ID = current.ID
+		model.LogoImage = current.LogoImage
+		model.Favicon = current.Favicon
+	}
+
+	return s.save(ctx, &model, changedBy)
+}
+
+// PatchSettings applies only the fields present in req on top of the current
+// settings row, so unset booleans and numbers keep their stored value.
+func (s service) PatchSettings(ctx context.Context, req map[string]interface{}, changedBy string) (
+	*domain.MainTable, *exception.Exception,
+) {
+	current, err := s.settingsRepo.FindSettings(ctx)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings", err)
+	}
+	if current == nil {
+		current = &domain.MainTable{}
+	}
+	id, logo, favicon := current.ID, current.LogoImage, current.Favicon
+
+	// id and updated_at are managed by the service, the images by UploadImage
+	delete(req, "id")
+	delete(req, "updated_at")
+	delete(req, "logo_image")
+	delete(req, "favicon")
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, exception.Internal("failed to encode settings", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(current); err != nil {
+		return nil, exception.Validation(map[string]string{"body": err.Error()})
+	}
+	current.ID = id
+	current.LogoImage, current.Favicon = logo, favicon
+
+	return s.save(ctx, current, changedBy)
+}
+
+func (s service) save(ctx context.Context, model *domain.MainTable, changedBy string) (
+	*domain.MainTable, *exception.Exception,
+) {
+	if errMap := s.validate.Struct(model); errMap != nil {
+		return nil, exception.Validation(errMap)
+	}
+
+	now := time.Now()
+	model.UpdatedAt = &now
+	revision := &domain.SettingsRevision{
+		Action:    domain.RevisionActionUpdate,
+		ChangedBy: changedBy,
+	}
+	if err := s.settingsRepo.UpdateSettings(ctx, model, revision); err != nil {
+		return nil, revisionError("failed to update settings", err)
+	}
+	return model, nil
+}
+
+func (s service) FindRevisions(ctx context.Context, paginate *baseModel.Paginate) (
+	[]domain.SettingsRevision, *exception.Exception,
+) {
+	result, err := s.settingsRepo.FindRevisions(ctx, paginate)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings revisions", err)
+	}
+	return result, nil
+}
+
+func (s service) FindRevision(ctx context.Context, revision int) (*domain.RevisionDetail, *exception.Exception) {
+	result, err := s.settingsRepo.FindRevision(ctx, revision)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings revision", err)
+	}
+	if result == nil {
+		return nil, exception.NotFound(notFound)
+	}
+
+	settings, err := result.GetSnapshot()
+	if err != nil {
+		return nil, exception.Internal("failed to read settings revision", err)
+	}
+	changes, err := result.GetDiff()
+	if err != nil {
+		return nil, exception.Internal("failed to read settings revision", err)
+	}
+	return &domain.RevisionDetail{
+		SettingsRevision: result,
+		Settings:         settings,
+		Changes:          changes,
+	}, nil
 }
 
-func (s service) FindSettings(ctx context.Context) (*domain.MainTable, error) {
+// DiffRevisions compares the snapshots of two revisions.
+func (s service) DiffRevisions(ctx context.Context, from, to int) (*domain.RevisionDiff, *exception.Exception) {
+	fromRevision, err := s.settingsRepo.FindRevision(ctx, from)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings revision", err)
+	}
+	toRevision, err := s.settingsRepo.FindRevision(ctx, to)
+	if err != nil {
+		return nil, exception.Internal("failed to find settings revision", err)
+	}
+	if fromRevision == nil || toRevision == nil {
+		return nil, exception.NotFound(notFound)
+	}
+
+	fromSettings, err := fromRevision.GetSnapshot()
+	if err != nil {
+		return nil, exception.Internal("failed to read settings revision", err)
+	}
+	toSettings, err := toRevision.GetSnapshot()
+	if err != nil {
+		return nil, exception.Internal("failed to read settings revision", err)
+	}
+	return &domain.RevisionDiff{
+		From:    from,
+		To:      to,
+		Changes: domain.DiffSettings(fromSettings, toSettings),
+	}, nil
+}
+
+// RollbackSettings restores the settings of revision. Images replaced since
+// the revision was taken are gone from the storage, they are cleared instead
+// of restoring a dangling filename.
+func (s service) RollbackSettings(ctx context.Context, revision int, changedBy string) (
+	*domain.MainTable, *exception.Exception,
+) {
+	result, _, err := s.settingsRepo.RollbackSettings(ctx, revision, changedBy, func(model *domain.MainTable) error {
+		for _, kind := range []string{domain.ImageLogo, domain.ImageFavicon} {
+			filename := model.Image(kind)
+			if filename == "" {
+				continue
+			}
+			if _, err := s.store.Stat(ctx, filename); err != nil {
+				if !errors.Is(err, storage.ErrNotFound) {
+					return err
+				}
+				logrus.Warnln("settings image of revision", revision, "no longer exists, clearing", kind, filename)
+				model.SetImage(kind, "")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, revisionError("failed to roll back settings", err)
+	}
+	if result == nil {
+		return nil, exception.NotFound(notFound)
+	}
+	return result, nil
+}
+
+// revisionError reports a concurrent writer that took the same revision
+// number as a conflict, the client can retry against the new settings.
+func revisionError(message string, err error) *exception.Exception {
+	if baseModel.IsDuplicateKey(err) {
+		exc := exception.Conflict("settings were changed by another request, please retry")
+		exc.Cause = err
+		return exc
+	}
+	return exception.Internal(message, err)
+}
+
+// PasswordPolicy returns the policy stored in the settings row, it makes the
+// service usable as a password.PolicySource.
+func (s service) PasswordPolicy(ctx context.Context) (*password.Policy, error) {
 	result, err := s.settingsRepo.FindSettings(ctx)
 	if err != nil {
-		return nil, errs.Wrap(err)
+		return nil, err
+	}
+	if result == nil {
+		result = &domain.MainTable{}
+	}
+	return result.PasswordPolicy(), nil
+}
+
+// UploadImage validates a base64 encoded image, stores it under a new name and
+// points the settings row to it. The previous file is removed only once the
+// row is updated, so a failed update never leaves the settings without a file.
+func (s service) UploadImage(ctx context.Context, kind, data string, changedBy string) (
+	*domain.MainTable, *exception.Exception,
+) {
+	ext, err := filevalidation.ValidateImage(data, int64(s.config.AppEnvConfig.FileMaxSize))
+	if err != nil {
+		return nil, exception.Validation(map[string]string{"image": err.Error()})
+	}
+	content, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, exception.Validation(map[string]string{"image": err.Error()})
+	}
+
+	filename := uuid.NewString() + "-" + time.Now().Format("02-January-2006") + ext
+	contentType := mimetype.Detect(content).String()
+	if err := s.store.Put(ctx, filename, bytes.NewReader(content), int64(len(content)), contentType); err != nil {
+		return nil, exception.Internal("failed to store settings image", err)
+	}
+
+	var previous string
+	revision := &domain.SettingsRevision{
+		Action:    domain.RevisionActionUpdate,
+		ChangedBy: changedBy,
+	}
+	result, err := s.settingsRepo.ModifySettings(ctx, func(model *domain.MainTable) error {
+		previous = model.Image(kind)
+		model.SetImage(kind, filename)
+		return nil
+	}, revision)
+	if err != nil {
+		_ = s.store.Delete(ctx, filename)
+		return nil, revisionError("failed to update settings", err)
+	}
+
+	if previous != "" && !result.IsImageReferenced(previous) {
+		if err := s.store.Delete(ctx, previous); err != nil {
+			logrus.Warnln("failed to remove previous settings image", previous, err.Error())
+		}
 	}
 	return result, nil
 }
+
+// OpenImage returns the content of the image stored for kind, NotFound when
+// no image is set or the file is missing.
+func (s service) OpenImage(ctx context.Context, kind string) (io.ReadCloser, *storage.Object, *exception.Exception) {
+	result, err := s.settingsRepo.FindSettings(ctx)
+	if err != nil {
+		return nil, nil, exception.Internal("failed to find settings", err)
+	}
+	if result == nil || result.Image(kind) == "" {
+		return nil, nil, exception.NotFound(notFound)
+	}
+
+	reader, object, err := s.store.Get(ctx, result.Image(kind))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, exception.NotFound(notFound)
+		}
+		return nil, nil, exception.Internal("failed to open settings image", err)
+	}
+	return reader, object, nil
+}
@@ -0,0 +1,16 @@
+package domain
+
+type VerifyRequest struct {
+	Type        string `json:"type" validate:"required,oneof=email phone_number" name:"type"`
+	Email       string `json:"email" validate:"email_if_type" name:"email"`
+	PhoneNumber string `json:"phone_number" validate:"phone_if_type" name:"phone_number"`
+	Code        string `json:"code" validate:"required,len=6" name:"code"`
+}
+
+// Target returns the email or phone number selected by Type.
+func (req *VerifyRequest) Target() string {
+	if req.Type == "phone_number" {
+		return req.PhoneNumber
+	}
+	return req.Email
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"boiler-plate/internal/base/app"
+	"boiler-plate/internal/base/handler"
+	"boiler-plate/internal/verification/domain"
+	VerificationService "boiler-plate/internal/verification/service"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/server"
+	"net/http"
+)
+
+type HTTPHandler struct {
+	App                 *handler.BaseHTTPHandler
+	VerificationService VerificationService.Service
+}
+
+func NewHTTPHandler(
+	handler *handler.BaseHTTPHandler, verificationService VerificationService.Service,
+) *HTTPHandler {
+	return &HTTPHandler{
+		App:                 handler,
+		VerificationService: verificationService,
+	}
+}
+
+func (h HTTPHandler) Verify(ctx *app.Context) *server.ResponseInterface {
+	var request domain.VerifyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return h.App.AsException(ctx, exception.InvalidArgument(err.Error()))
+	}
+
+	if exc := h.VerificationService.Verify(ctx, &request); exc != nil {
+		return h.App.AsException(ctx, exc)
+	}
+	return h.App.AsData(ctx, http.StatusOK, handler.MessageSuccess, nil)
+}
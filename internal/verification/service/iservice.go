@@ -0,0 +1,13 @@
+package service
+
+import (
+	accountDomain "boiler-plate/internal/account/domain"
+	"boiler-plate/internal/verification/domain"
+	"boiler-plate/pkg/exception"
+	"context"
+)
+
+type Service interface {
+	Issue(ctx context.Context, account *accountDomain.Account, verificationType string) *exception.Exception
+	Verify(ctx context.Context, req *domain.VerifyRequest) *exception.Exception
+}
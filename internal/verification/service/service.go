@@ -0,0 +1,84 @@
+package service
+
+import (
+	"boiler-plate/app/appconf"
+	accountDomain "boiler-plate/internal/account/domain"
+	accountRepository "boiler-plate/internal/account/repository"
+	otpDomain "boiler-plate/internal/otp/domain"
+	otpService "boiler-plate/internal/otp/service"
+	"boiler-plate/internal/verification/domain"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/xvalidator"
+	"context"
+	"time"
+)
+
+// NewService creates new verification service
+func NewService(
+	config *appconf.Config, accountRepo accountRepository.Repository, otp otpService.Service,
+	validate *xvalidator.Validator,
+) Service {
+	return &service{
+		config:      config,
+		accountRepo: accountRepo,
+		otp:         otp,
+		validate:    validate,
+	}
+}
+
+type service struct {
+	config      *appconf.Config
+	accountRepo accountRepository.Repository
+	otp         otpService.Service
+	validate    *xvalidator.Validator
+}
+
+// Issue sends a registration otp to the account email or phone number.
+func (s service) Issue(ctx context.Context, account *accountDomain.Account, verificationType string) *exception.Exception {
+	channel, target := otpDomain.ChannelEmail, account.Email
+	if verificationType == accountDomain.VerificationTypePhone {
+		channel, target = otpDomain.ChannelSMS, account.Phone
+	}
+	_, exc := s.otp.Send(ctx, otpDomain.PurposeRegistration, channel, target, map[string]string{
+		"name": account.Fullname,
+	})
+	return exc
+}
+
+func (s service) Verify(ctx context.Context, req *domain.VerifyRequest) *exception.Exception {
+	if errMap := s.validate.Struct(req); errMap != nil {
+		return exception.Validation(errMap)
+	}
+
+	var (
+		account *accountDomain.Account
+		err     error
+	)
+	channel := otpDomain.ChannelEmail
+	if req.Type == accountDomain.VerificationTypePhone {
+		channel = otpDomain.ChannelSMS
+		account, err = s.accountRepo.FindByPhone(ctx, req.PhoneNumber)
+	} else {
+		account, err = s.accountRepo.FindByEmail(ctx, req.Email)
+	}
+	if err != nil {
+		return exception.Internal("failed to find account", err)
+	}
+	if account == nil {
+		return exception.NotFound("account not found")
+	}
+
+	if exc := s.otp.Verify(ctx, &otpDomain.VerifyOTPRequest{
+		Purpose: otpDomain.PurposeRegistration,
+		Channel: channel,
+		Target:  req.Target(),
+		Code:    req.Code,
+	}); exc != nil {
+		return exc
+	}
+
+	if err := s.accountRepo.MarkVerified(ctx, account.ID, req.Type, time.Now()); err != nil {
+		return exception.Internal("failed to verify account", err)
+	}
+	return nil
+}
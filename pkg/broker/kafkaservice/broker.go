@@ -0,0 +1,33 @@
+package kafkaservice
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Broker creates readers and writers for topics. KafkaService talks to a
+// real cluster, memkafka.Broker keeps everything in memory for tests.
+type Broker interface {
+	// NewReader joins groupID on topic, an empty groupID reads every
+	// partition from the start without committing.
+	NewReader(topic string, groupID string) Reader
+	NewWriter(topic string) Writer
+	// Publish writes messages to topic, keeping the order of messages with
+	// the same key.
+	Publish(ctx context.Context, topic string, messages ...kafka.Message) error
+	Close() error
+}
+
+// Reader is implemented by *kafka.Reader.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Writer is implemented by *kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
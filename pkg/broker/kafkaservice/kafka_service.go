@@ -1,10 +1,15 @@
 package kafkaservice
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl"
@@ -17,6 +22,17 @@ type Config struct {
 	Brokers          []string
 	Username         string
 	Password         string
+	TLS              *TLSConfig
+}
+
+// TLSConfig enables TLS for any protocol, it is implied by SSL and SASL_SSL.
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted on top of the system roots.
+	CAFile string
+	// CertFile and KeyFile are the client certificate for mutual TLS.
+	CertFile   string
+	KeyFile    string
+	ServerName string
 }
 
 type KafkaService struct {
@@ -25,63 +41,108 @@ type KafkaService struct {
 	tls         *tls.Config
 	errorLogger kafka.Logger
 	logger      kafka.Logger
+
+	writersMu sync.Mutex
+	writers   map[string]*kafka.Writer
 }
 
-func New(config *Config) *KafkaService {
-	var mechanism sasl.Mechanism
-	var tlsConfig *tls.Config
+func New(config *Config) (*KafkaService, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("kafka: no brokers configured")
+	}
 
-	if config.SecurityProtocol == "SCRAM_SHA_256" {
-		var err error
+	var (
+		mechanism sasl.Mechanism
+		err       error
+	)
+	tlsConfig := config.TLS
+	switch config.SecurityProtocol {
+	case "SCRAM_SHA_256":
 		mechanism, err = scram.Mechanism(scram.SHA256, config.Username, config.Password)
-		if err != nil {
-			slog.Error("can't setup kafka server", "error", err)
-			os.Exit(1)
-		}
-	} else if config.SecurityProtocol == "SCRAM_SHA_512" {
-		var err error
+	case "SCRAM_SHA_512":
 		mechanism, err = scram.Mechanism(scram.SHA512, config.Username, config.Password)
-		if err != nil {
-			slog.Error("can't setup kafka server", "error", err)
-			os.Exit(1)
-		}
-	} else if config.SecurityProtocol == "SASL_SSL" {
+	case "SASL_SSL":
 		mechanism = plain.Mechanism{
 			Username: config.Username,
 			Password: config.Password,
 		}
-		tlsConfig = &tls.Config{}
-	} else if config.SecurityProtocol == "SASL_PLAIN" {
+		if tlsConfig == nil {
+			tlsConfig = &TLSConfig{}
+		}
+	case "SASL_PLAIN":
 		mechanism = plain.Mechanism{
 			Username: config.Username,
 			Password: config.Password,
 		}
-		tlsConfig = nil
-	} else if config.SecurityProtocol == "PLAIN" {
-		mechanism = nil
-		tlsConfig = nil
-	} else {
-		slog.Error("can't setup kafka server", "error", "invalid security protocol")
-		os.Exit(1)
+	case "SSL":
+		if tlsConfig == nil {
+			tlsConfig = &TLSConfig{}
+		}
+	case "PLAIN":
+	default:
+		return nil, fmt.Errorf("kafka: invalid security protocol %q", config.SecurityProtocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kafka: setup %s: %w", config.SecurityProtocol, err)
 	}
 
-	slog.Debug(fmt.Sprintf("kafka security protocol %s", config.SecurityProtocol))
+	var clientTLS *tls.Config
+	if tlsConfig != nil {
+		if clientTLS, err = tlsConfig.build(); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Debug(fmt.Sprintf("kafka security protocol %s, tls %t", config.SecurityProtocol, clientTLS != nil))
 	slog.Debug("kafka module initialized")
 
 	return &KafkaService{
 		brokers:   config.Brokers,
 		mechanism: mechanism,
-		tls:       tlsConfig,
+		tls:       clientTLS,
 		errorLogger: kafka.LoggerFunc(func(message string, args ...interface{}) {
 			slog.Error(fmt.Sprintf(message, args...))
 		}),
 		// logger: kafka.LoggerFunc(func(message string, args ...interface{}) {
 		// 	slog.Debug(fmt.Sprintf(message, args...))
 		// }),
+	}, nil
+}
+
+func (c *TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		bundle, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("kafka: no certificate found in %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
 	}
+	return config, nil
 }
 
-func (k *KafkaService) NewReader(topic string, groupID string) *kafka.Reader {
+var _ Broker = (*KafkaService)(nil)
+
+func (k *KafkaService) NewReader(topic string, groupID string) Reader {
 	return kafka.NewReader(kafka.ReaderConfig{
 		Brokers: k.brokers,
 		Topic:   topic,
@@ -95,7 +156,11 @@ func (k *KafkaService) NewReader(topic string, groupID string) *kafka.Reader {
 	})
 }
 
-func (k *KafkaService) NewWriter(topic string) *kafka.Writer {
+func (k *KafkaService) NewWriter(topic string) Writer {
+	return k.newWriter(topic)
+}
+
+func (k *KafkaService) newWriter(topic string) *kafka.Writer {
 	return &kafka.Writer{
 		Addr:     kafka.TCP(k.brokers...),
 		Topic:    topic,
@@ -108,3 +173,39 @@ func (k *KafkaService) NewWriter(topic string) *kafka.Writer {
 		Logger:      k.logger,
 	}
 }
+
+// Publish writes messages to topic and waits until every replica has
+// acknowledged them. Messages with the same key go to the same partition, so
+// their order is kept. Writers are created once per topic and reused.
+func (k *KafkaService) Publish(ctx context.Context, topic string, messages ...kafka.Message) error {
+	k.writersMu.Lock()
+	if k.writers == nil {
+		k.writers = map[string]*kafka.Writer{}
+	}
+	writer, ok := k.writers[topic]
+	if !ok {
+		writer = k.newWriter(topic)
+		writer.Balancer = &kafka.Hash{}
+		writer.RequiredAcks = kafka.RequireAll
+		writer.BatchTimeout = 10 * time.Millisecond
+		k.writers[topic] = writer
+	}
+	k.writersMu.Unlock()
+
+	return writer.WriteMessages(ctx, messages...)
+}
+
+// Close flushes and closes the writers opened by Publish.
+func (k *KafkaService) Close() error {
+	k.writersMu.Lock()
+	defer k.writersMu.Unlock()
+
+	var firstErr error
+	for topic, writer := range k.writers {
+		if err := writer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(k.writers, topic)
+	}
+	return firstErr
+}
@@ -0,0 +1,390 @@
+package memkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"boiler-plate/pkg/broker/kafkaservice"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrNoGroup is returned when committing from a reader without a group, as
+// kafka-go does.
+var ErrNoGroup = errors.New("memkafka: commit is unavailable when GroupID is not set")
+
+// ErrNotAssigned is returned when committing a message fetched before a
+// rebalance took its partition away or restarted it from the committed
+// offset. It wraps kafka.IllegalGeneration like the error of a real broker.
+var ErrNotAssigned = fmt.Errorf("memkafka: message was fetched before the group rebalanced: %w", kafka.IllegalGeneration)
+
+// Broker is an in-memory kafkaservice.Broker for tests. Topics are created on
+// first use with the default number of partitions. Readers sharing a group
+// split the partitions between them and start from the committed offsets,
+// every join or leave rebalances the group like a real broker, so
+// uncommitted messages are delivered again.
+type Broker struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[string]*topic
+	groups     map[groupKey]*group
+	balancer   kafka.Balancer
+	changed    chan struct{}
+	closed     bool
+}
+
+type topic struct {
+	partitions [][]kafka.Message
+}
+
+type groupKey struct {
+	group string
+	topic string
+}
+
+type group struct {
+	committed map[int]int64
+	members   []*Reader
+}
+
+var _ kafkaservice.Broker = (*Broker)(nil)
+
+// New creates a broker whose topics have partitions partitions unless
+// created with CreateTopic.
+func New(partitions int) *Broker {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &Broker{
+		partitions: partitions,
+		topics:     map[string]*topic{},
+		groups:     map[groupKey]*group{},
+		balancer:   &kafka.Hash{},
+		changed:    make(chan struct{}),
+	}
+}
+
+// CreateTopic creates name with its own number of partitions. It fails when
+// the topic already exists with a different count.
+func (b *Broker) CreateTopic(name string, partitions int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.topics[name]; ok {
+		if len(t.partitions) != partitions {
+			return fmt.Errorf("memkafka: topic %s exists with %d partitions", name, len(t.partitions))
+		}
+		return nil
+	}
+	b.topics[name] = &topic{partitions: make([][]kafka.Message, partitions)}
+	return nil
+}
+
+// Messages returns every message written to name, ordered by partition and
+// offset.
+func (b *Broker) Messages(name string) []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var messages []kafka.Message
+	if t, ok := b.topics[name]; ok {
+		for _, partition := range t.partitions {
+			messages = append(messages, partition...)
+		}
+	}
+	return messages
+}
+
+// CommittedOffset returns the next offset groupID reads from partition, zero
+// when nothing was committed.
+func (b *Broker) CommittedOffset(groupID, name string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g, ok := b.groups[groupKey{group: groupID, topic: name}]; ok {
+		return g.committed[partition]
+	}
+	return 0
+}
+
+// Lag returns the number of messages of name not yet committed by groupID.
+func (b *Broker) Lag(groupID, name string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.topics[name]
+	if !ok {
+		return 0
+	}
+	g := b.groups[groupKey{group: groupID, topic: name}]
+	var lag int64
+	for p, partition := range t.partitions {
+		lag += int64(len(partition))
+		if g != nil {
+			lag -= g.committed[p]
+		}
+	}
+	return lag
+}
+
+func (b *Broker) NewReader(name string, groupID string) kafkaservice.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.topic(name)
+	r := &Reader{broker: b, topic: name, groupID: groupID, positions: map[int]int64{}}
+	if groupID == "" {
+		for p := range t.partitions {
+			r.assigned = append(r.assigned, p)
+		}
+		return r
+	}
+
+	key := groupKey{group: groupID, topic: name}
+	g, ok := b.groups[key]
+	if !ok {
+		g = &group{committed: map[int]int64{}}
+		b.groups[key] = g
+	}
+	g.members = append(g.members, r)
+	b.rebalance(g, t)
+	return r
+}
+
+func (b *Broker) NewWriter(name string) kafkaservice.Writer {
+	return &Writer{broker: b, topic: name, balancer: &kafka.Hash{}}
+}
+
+func (b *Broker) Publish(ctx context.Context, name string, messages ...kafka.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.write(name, b.balancer, messages)
+}
+
+// Close stops every reader, pending fetches return io.EOF.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.broadcast()
+	return nil
+}
+
+func (b *Broker) write(name string, balancer kafka.Balancer, messages []kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return io.ErrClosedPipe
+	}
+	t := b.topic(name)
+	ids := make([]int, len(t.partitions))
+	for p := range ids {
+		ids[p] = p
+	}
+
+	now := time.Now()
+	for _, msg := range messages {
+		msg.Topic = name
+		msg.Partition = balancer.Balance(msg, ids...)
+		msg.Offset = int64(len(t.partitions[msg.Partition]))
+		msg.Key = append([]byte(nil), msg.Key...)
+		msg.Value = append([]byte(nil), msg.Value...)
+		msg.Headers = append([]kafka.Header(nil), msg.Headers...)
+		if msg.Time.IsZero() {
+			msg.Time = now
+		}
+		t.partitions[msg.Partition] = append(t.partitions[msg.Partition], msg)
+	}
+	b.broadcast()
+	return nil
+}
+
+// topic must be called with mu held.
+func (b *Broker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{partitions: make([][]kafka.Message, b.partitions)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// rebalance spreads the partitions of t over the members of g, each member
+// restarts from the committed offsets. It must be called with mu held.
+func (b *Broker) rebalance(g *group, t *topic) {
+	for _, member := range g.members {
+		member.assigned = nil
+		member.positions = map[int]int64{}
+		member.next = 0
+	}
+	if len(g.members) == 0 {
+		return
+	}
+	for p := range t.partitions {
+		member := g.members[p%len(g.members)]
+		member.assigned = append(member.assigned, p)
+		member.positions[p] = g.committed[p]
+	}
+	b.broadcast()
+}
+
+// broadcast wakes up waiting readers, it must be called with mu held.
+func (b *Broker) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Reader is a kafkaservice.Reader on a Broker.
+type Reader struct {
+	broker    *Broker
+	topic     string
+	groupID   string
+	assigned  []int
+	positions map[int]int64
+	next      int
+	closed    bool
+}
+
+// FetchMessage blocks until a message is available on an assigned
+// partition, ctx is done or the reader is closed.
+func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		if r.closed || r.broker.closed {
+			r.broker.mu.Unlock()
+			return kafka.Message{}, io.EOF
+		}
+		if msg, ok := r.poll(); ok {
+			r.broker.mu.Unlock()
+			return msg, nil
+		}
+		changed := r.broker.changed
+		r.broker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// poll takes the next message, visiting the assigned partitions in turn. It
+// must be called with mu held.
+func (r *Reader) poll() (kafka.Message, bool) {
+	t := r.broker.topics[r.topic]
+	for i := range r.assigned {
+		index := (r.next + i) % len(r.assigned)
+		p := r.assigned[index]
+		partition := t.partitions[p]
+		position := r.positions[p]
+		if position >= int64(len(partition)) {
+			continue
+		}
+		msg := partition[position]
+		msg.HighWaterMark = int64(len(partition))
+		r.positions[p] = position + 1
+		r.next = (index + 1) % len(r.assigned)
+		return msg, true
+	}
+	return kafka.Message{}, false
+}
+
+func (r *Reader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if r.groupID == "" {
+		return ErrNoGroup
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+	if r.closed {
+		return io.EOF
+	}
+	// a message is only committed by the member it was delivered to since the
+	// last rebalance, the positions restart from the committed offsets
+	for _, msg := range msgs {
+		if !r.owns(msg.Partition) || msg.Offset >= r.positions[msg.Partition] {
+			return ErrNotAssigned
+		}
+	}
+	g := r.broker.groups[groupKey{group: r.groupID, topic: r.topic}]
+	for _, msg := range msgs {
+		if msg.Offset+1 > g.committed[msg.Partition] {
+			g.committed[msg.Partition] = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+// owns reports whether partition is assigned to r. It must be called with mu
+// held.
+func (r *Reader) owns(partition int) bool {
+	for _, p := range r.assigned {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
+
+// Close leaves the group, its partitions move to the remaining members.
+func (r *Reader) Close() error {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.groupID == "" {
+		r.broker.broadcast()
+		return nil
+	}
+
+	g := r.broker.groups[groupKey{group: r.groupID, topic: r.topic}]
+	for i, member := range g.members {
+		if member == r {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	r.broker.rebalance(g, r.broker.topics[r.topic])
+	r.broker.broadcast()
+	return nil
+}
+
+// Writer is a kafkaservice.Writer on a Broker, messages with the same key go
+// to the same partition.
+type Writer struct {
+	broker   *Broker
+	topic    string
+	balancer kafka.Balancer
+	mu       sync.Mutex
+	closed   bool
+}
+
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return io.ErrClosedPipe
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if msg.Topic != "" {
+			return errors.New("memkafka: message topic must not be set when the writer has a topic")
+		}
+	}
+	return w.broker.write(w.topic, w.balancer, msgs)
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
@@ -0,0 +1,203 @@
+package memkafka
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetch(t *testing.T, r *Reader) kafka.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := r.FetchMessage(ctx)
+	require.NoError(t, err)
+	return msg
+}
+
+func publish(t *testing.T, b *Broker, topic string, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		require.NoError(t, b.Publish(context.Background(), topic, kafka.Message{Key: []byte(key), Value: []byte("value-" + key)}))
+	}
+}
+
+func TestCreateTopic(t *testing.T) {
+	b := New(2)
+	require.NoError(t, b.CreateTopic("orders", 3))
+	require.NoError(t, b.CreateTopic("orders", 3))
+	assert.Error(t, b.CreateTopic("orders", 4))
+
+	publish(t, b, "orders", "a", "b", "c", "d", "e", "f")
+	publish(t, b, "events", "a")
+	assert.Len(t, b.topics["orders"].partitions, 3)
+	assert.Len(t, b.topics["events"].partitions, 2, "topics created on first use get the default count")
+}
+
+func TestPublishKeepsKeysOnOnePartition(t *testing.T) {
+	b := New(4)
+	publish(t, b, "orders", "a", "b", "a", "c", "a")
+
+	messages := b.Messages("orders")
+	require.Len(t, messages, 5)
+	partition := -1
+	var offsets []int64
+	for _, msg := range messages {
+		assert.Equal(t, "orders", msg.Topic)
+		if string(msg.Key) != "a" {
+			continue
+		}
+		if partition == -1 {
+			partition = msg.Partition
+		}
+		assert.Equal(t, partition, msg.Partition)
+		offsets = append(offsets, msg.Offset)
+	}
+	assert.Len(t, offsets, 3)
+	assert.IsIncreasing(t, offsets)
+}
+
+func TestGroupSplitsPartitions(t *testing.T) {
+	b := New(4)
+	first := b.NewReader("orders", "billing").(*Reader)
+	second := b.NewReader("orders", "billing").(*Reader)
+	other := b.NewReader("orders", "audit").(*Reader)
+
+	assert.ElementsMatch(t, []int{0, 2}, first.assigned)
+	assert.ElementsMatch(t, []int{1, 3}, second.assigned)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, other.assigned, "each group gets every partition")
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	publish(t, b, "orders", keys...)
+
+	seen := map[string]int{}
+	for range keys {
+		msg := fetch(t, other)
+		seen[string(msg.Key)]++
+	}
+	assert.Len(t, seen, len(keys))
+
+	split := map[string]int{}
+	for _, r := range []*Reader{first, second} {
+		for _, p := range r.assigned {
+			for range b.topics["orders"].partitions[p] {
+				msg := fetch(t, r)
+				assert.Contains(t, r.assigned, msg.Partition)
+				split[string(msg.Key)]++
+			}
+		}
+	}
+	assert.Equal(t, seen, split, "the members of a group read every message once")
+}
+
+func TestCommitAndResume(t *testing.T) {
+	b := New(1)
+	publish(t, b, "orders", "a", "b", "c")
+
+	r := b.NewReader("orders", "billing").(*Reader)
+	first := fetch(t, r)
+	second := fetch(t, r)
+	require.NoError(t, r.CommitMessages(context.Background(), first))
+	assert.Equal(t, int64(1), b.CommittedOffset("billing", "orders", 0))
+	assert.Equal(t, int64(2), b.Lag("billing", "orders"))
+	require.NoError(t, r.Close())
+
+	// the uncommitted message is delivered again to the next member
+	r = b.NewReader("orders", "billing").(*Reader)
+	msg := fetch(t, r)
+	assert.Equal(t, second.Offset, msg.Offset)
+	assert.Equal(t, "b", string(msg.Key))
+	require.NoError(t, r.CommitMessages(context.Background(), msg, fetch(t, r)))
+	assert.Equal(t, int64(3), b.CommittedOffset("billing", "orders", 0))
+	assert.Zero(t, b.Lag("billing", "orders"))
+
+	require.NoError(t, r.CommitMessages(context.Background(), first), "committing an older offset keeps the committed offset")
+	assert.Equal(t, int64(3), b.CommittedOffset("billing", "orders", 0))
+}
+
+func TestCommitAfterRebalanceIsRejected(t *testing.T) {
+	b := New(2)
+	require.NoError(t, b.CreateTopic("orders", 2))
+	first := b.NewReader("orders", "billing").(*Reader)
+	publish(t, b, "orders", "a", "b", "c", "d")
+
+	fetched := map[int]kafka.Message{}
+	for len(fetched) < 2 {
+		msg := fetch(t, first)
+		if _, ok := fetched[msg.Partition]; !ok {
+			fetched[msg.Partition] = msg
+		}
+	}
+
+	// the second member takes partition 1, partition 0 stays with the first
+	// member but restarts from the committed offset
+	second := b.NewReader("orders", "billing").(*Reader)
+	for p, msg := range fetched {
+		err := first.CommitMessages(context.Background(), msg)
+		assert.ErrorIs(t, err, ErrNotAssigned, "partition %d", p)
+		assert.ErrorIs(t, err, kafka.IllegalGeneration)
+		assert.Zero(t, b.CommittedOffset("billing", "orders", p))
+	}
+
+	again := fetch(t, second)
+	assert.Equal(t, 1, again.Partition)
+	assert.Equal(t, int64(0), again.Offset)
+	require.NoError(t, second.CommitMessages(context.Background(), again))
+
+	require.NoError(t, second.Close())
+	assert.ErrorIs(t, second.CommitMessages(context.Background(), again), io.EOF)
+}
+
+func TestReaderWithoutGroup(t *testing.T) {
+	b := New(2)
+	publish(t, b, "orders", "a", "b", "c")
+
+	r := b.NewReader("orders", "").(*Reader)
+	for range []string{"a", "b", "c"} {
+		fetch(t, r)
+	}
+	assert.ErrorIs(t, r.CommitMessages(context.Background(), kafka.Message{}), ErrNoGroup)
+}
+
+func TestFetchWaitsForMessages(t *testing.T) {
+	b := New(1)
+	r := b.NewReader("orders", "billing").(*Reader)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := r.FetchMessage(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	time.AfterFunc(10*time.Millisecond, func() {
+		_ = b.Publish(context.Background(), "orders", kafka.Message{Key: []byte("a")})
+	})
+	assert.Equal(t, "a", string(fetch(t, r).Key))
+
+	done := make(chan error)
+	go func() {
+		_, err := r.FetchMessage(context.Background())
+		done <- err
+	}()
+	require.NoError(t, b.Close())
+	assert.ErrorIs(t, <-done, io.EOF)
+	assert.ErrorIs(t, b.Publish(context.Background(), "orders"), io.ErrClosedPipe)
+}
+
+func TestWriter(t *testing.T) {
+	b := New(1)
+	w := b.NewWriter("orders")
+	require.NoError(t, w.WriteMessages(context.Background(), kafka.Message{Key: []byte("a")}))
+	assert.Error(t, w.WriteMessages(context.Background(), kafka.Message{Topic: "other"}))
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.WriteMessages(context.Background(), kafka.Message{}), io.ErrClosedPipe)
+	assert.Len(t, b.Messages("orders"), 1)
+}
@@ -0,0 +1,39 @@
+package captcha
+
+import (
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// Captcha generates and verifies digit image captchas kept in memory.
+type Captcha struct {
+	captcha *base64Captcha.Captcha
+	enabled bool
+}
+
+// New creates a Captcha whose answers expire after expiration. When enabled is
+// false Verify accepts any answer, which is meant for local development only.
+func New(expiration time.Duration, enabled bool) *Captcha {
+	driver := base64Captcha.NewDriverDigit(66, 150, 4, 0.7, 80)
+	store := base64Captcha.NewMemoryStore(base64Captcha.GCLimitNumber, expiration)
+	return &Captcha{
+		captcha: base64Captcha.NewCaptcha(driver, store),
+		enabled: enabled,
+	}
+}
+
+// Generate returns the key to send back with the answer and the captcha image
+// as a base64 data URI.
+func (c *Captcha) Generate() (key string, image string, err error) {
+	key, image, _, err = c.captcha.Generate()
+	return key, image, err
+}
+
+// Verify checks the answer of key, a captcha can only be verified once.
+func (c *Captcha) Verify(key, answer string) bool {
+	if !c.enabled {
+		return true
+	}
+	return c.captcha.Verify(key, answer, true)
+}
@@ -0,0 +1,274 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"boiler-plate/pkg/event"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers added to a message routed to the dead-letter topic.
+const (
+	HeaderError             = "x-dlq-error"
+	HeaderReason            = "x-dlq-reason"
+	HeaderAttempts          = "x-dlq-attempts"
+	HeaderFailedAt          = "x-dlq-failed-at"
+	HeaderOriginalTopic     = "x-dlq-original-topic"
+	HeaderOriginalPartition = "x-dlq-original-partition"
+	HeaderOriginalOffset    = "x-dlq-original-offset"
+
+	ReasonPermanent        = "permanent"
+	ReasonRetriesExhausted = "retries_exhausted"
+)
+
+// Reader is the part of *kafka.Reader used by the consumer.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Publisher sends dead-lettered messages, *kafkaservice.KafkaService
+// implements it.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, messages ...kafka.Message) error
+}
+
+// Validator is implemented by events that check their own content after
+// decoding, a failure is a permanent error.
+type Validator interface {
+	Validate() error
+}
+
+// HandlerFunc processes one decoded event. msg is the raw Kafka message for
+// keys and headers.
+type HandlerFunc[T any] func(ctx context.Context, event *T, msg kafka.Message) error
+
+type Config struct {
+	Topic string
+	// DeadLetterTopic receives messages that failed permanently or ran out of
+	// retries. When empty they are logged and skipped.
+	DeadLetterTopic string
+	Publisher       Publisher
+	// MaxRetries before a failing message is dead-lettered, zero retries
+	// until it succeeds or the consumer stops.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Registry validates messages of EventType before they are decoded.
+	// Enveloped messages are checked against the version they name, bare
+	// payloads from producers without envelopes against the latest version.
+	Registry  *event.Registry
+	EventType string
+}
+
+// Consumer decodes JSON messages into T and hands them to a handler. The
+// offset of a message is committed only once it was handled or
+// dead-lettered. On shutdown the message in flight is finished and committed
+// before Run returns, pending retries are abandoned.
+type Consumer[T any] struct {
+	reader   Reader
+	handler  HandlerFunc[T]
+	config   *Config
+	registry *Registry
+}
+
+func New[T any](reader Reader, handler HandlerFunc[T], config *Config, registry *Registry) *Consumer[T] {
+	registry.register(config.Topic)
+	return &Consumer[T]{
+		reader:   reader,
+		handler:  handler,
+		config:   config,
+		registry: registry,
+	}
+}
+
+// Run consumes until ctx is cancelled. It returns nil on cancellation.
+func (c *Consumer[T]) Run(ctx context.Context) error {
+	defer c.reader.Close()
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		c.registry.observe(c.config.Topic, msg)
+
+		if err := c.registry.waitResumed(ctx, c.config.Topic); err != nil {
+			return nil
+		}
+		if err := c.process(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := c.reader.CommitMessages(context.WithoutCancel(ctx), msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Consumer[T]) process(ctx context.Context, msg kafka.Message) error {
+	log := logrus.WithFields(logrus.Fields{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+	})
+	start := time.Now()
+
+	// The attempt in flight is not cancelled by shutdown, only the retries are.
+	backoff := c.config.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.handle(context.WithoutCancel(ctx), msg)
+		if err == nil {
+			c.registry.processed(c.config.Topic, time.Since(start))
+			return nil
+		}
+		c.registry.failed(c.config.Topic, err)
+
+		if IsPermanent(err) {
+			log.WithError(err).Errorln("message failed permanently")
+			return c.deadLetter(ctx, msg, err, ReasonPermanent, attempt)
+		}
+		if c.config.MaxRetries > 0 && attempt > c.config.MaxRetries {
+			log.WithError(err).Errorf("message failed after %d attempts", attempt)
+			return c.deadLetter(ctx, msg, err, ReasonRetriesExhausted, attempt)
+		}
+		log.WithError(err).Warnf("failed to process message, retrying in %s", backoff)
+		c.registry.retried(c.config.Topic)
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+		if backoff *= 2; backoff > c.config.MaxBackoff {
+			backoff = c.config.MaxBackoff
+		}
+	}
+}
+
+func (c *Consumer[T]) handle(ctx context.Context, msg kafka.Message) error {
+	data, err := c.payload(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	value := new(T)
+	if err := json.Unmarshal(data, value); err != nil {
+		return Permanent(fmt.Errorf("decode message: %w", err))
+	}
+	if validator, ok := interface{}(value).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return Permanent(err)
+		}
+	}
+	return c.handler(ctx, value, msg)
+}
+
+// payload returns the event data of msg after checking it against the
+// registry.
+func (c *Consumer[T]) payload(msg kafka.Message) ([]byte, error) {
+	if c.config.Registry == nil {
+		return msg.Value, nil
+	}
+	if event.IsEnvelope(msg.Value) {
+		envelope, err := c.config.Registry.Decode(msg.Value)
+		if err != nil {
+			return nil, err
+		}
+		if c.config.EventType != "" && envelope.EventType != c.config.EventType {
+			return nil, fmt.Errorf("unexpected event type %s", envelope.EventType)
+		}
+		return envelope.Data, nil
+	}
+
+	version, ok := c.config.Registry.Latest(c.config.EventType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", event.ErrUnknownSchema, c.config.EventType)
+	}
+	if err := c.config.Registry.Validate(c.config.EventType, version, msg.Value); err != nil {
+		return nil, err
+	}
+	return msg.Value, nil
+}
+
+// deadLetter copies msg to the dead-letter topic with the failure in its
+// headers. Publishing is retried because the offset must not be committed
+// before the copy exists.
+func (c *Consumer[T]) deadLetter(ctx context.Context, msg kafka.Message, cause error, reason string, attempts int) error {
+	if c.config.DeadLetterTopic == "" {
+		logrus.WithError(cause).WithField("topic", msg.Topic).Errorln("no dead-letter topic, skipping message")
+		c.registry.deadLettered(c.config.Topic)
+		return nil
+	}
+
+	headers := append([]kafka.Header(nil), msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderReason, Value: []byte(reason)},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	dead := kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers}
+
+	backoff := c.config.MinBackoff
+	for {
+		err := c.config.Publisher.Publish(context.WithoutCancel(ctx), c.config.DeadLetterTopic, dead)
+		if err == nil {
+			c.registry.deadLettered(c.config.Topic)
+			return nil
+		}
+		logrus.WithError(err).Errorf("failed to publish to %s, retrying in %s", c.config.DeadLetterTopic, backoff)
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+		if backoff *= 2; backoff > c.config.MaxBackoff {
+			backoff = c.config.MaxBackoff
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, the message goes straight to
+// the dead-letter topic.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,200 @@
+package consumer
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TopicMetrics are the processing counters of every consumer instance
+// reading a topic.
+type TopicMetrics struct {
+	Paused bool `json:"paused"`
+	// Lag is the number of messages behind the high watermark of the
+	// partition of the last fetched message.
+	Lag             int64      `json:"lag"`
+	Processed       int64      `json:"processed"`
+	Failed          int64      `json:"failed"`
+	Retried         int64      `json:"retried"`
+	DeadLettered    int64      `json:"dead_lettered"`
+	AvgProcessingMs float64    `json:"avg_processing_ms"`
+	LastProcessedAt *time.Time `json:"last_processed_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+}
+
+type topicState struct {
+	TopicMetrics
+	totalProcessing time.Duration
+	partitionLag    map[int]int64
+	resume          chan struct{}
+}
+
+// Registry keeps the metrics and pause state shared by the consumers.
+type Registry struct {
+	mu     sync.Mutex
+	topics map[string]*topicState
+}
+
+func NewRegistry() *Registry {
+	return &Registry{topics: map[string]*topicState{}}
+}
+
+func (r *Registry) register(topic string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.topics[topic]; !ok {
+		r.topics[topic] = &topicState{partitionLag: map[int]int64{}}
+	}
+}
+
+// Pause stops the consumers of topic before they handle their next message.
+// It returns false when no consumer reads topic.
+func (r *Registry) Pause(topic string) bool {
+	return r.update(topic, func(m *topicState) {
+		if !m.Paused {
+			m.Paused = true
+			m.resume = make(chan struct{})
+		}
+	})
+}
+
+// Resume releases the consumers of a paused topic.
+func (r *Registry) Resume(topic string) bool {
+	return r.update(topic, func(m *topicState) {
+		if m.Paused {
+			m.Paused = false
+			close(m.resume)
+		}
+	})
+}
+
+// Metrics returns a copy of the metrics of every topic.
+func (r *Registry) Metrics() map[string]TopicMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make(map[string]TopicMetrics, len(r.topics))
+	for topic, m := range r.topics {
+		result[topic] = m.TopicMetrics
+	}
+	return result
+}
+
+// Handler serves the metrics on GET /consumers and pauses or resumes a topic
+// on POST /consumers/pause?topic= and /consumers/resume?topic=. The control
+// routes need "Authorization: Bearer <adminToken>" and are disabled when
+// adminToken is empty. Pausing only affects the consumers of this process,
+// every replica has to be paused on its own.
+func (r *Registry) Handler(adminToken string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/consumers", func(rw http.ResponseWriter, req *http.Request) {
+		writeJSON(rw, http.StatusOK, r.Metrics())
+	})
+	control := func(action func(string) bool) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				writeJSON(rw, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+				return
+			}
+			if adminToken == "" {
+				writeJSON(rw, http.StatusForbidden, map[string]string{"message": "consumer control is disabled"})
+				return
+			}
+			token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				writeJSON(rw, http.StatusUnauthorized, map[string]string{"message": "invalid admin token"})
+				return
+			}
+			topic := req.URL.Query().Get("topic")
+			if !action(topic) {
+				writeJSON(rw, http.StatusNotFound, map[string]string{"message": "unknown topic " + topic})
+				return
+			}
+			writeJSON(rw, http.StatusOK, r.Metrics()[topic])
+		}
+	}
+	mux.HandleFunc("/consumers/pause", control(r.Pause))
+	mux.HandleFunc("/consumers/resume", control(r.Resume))
+	return mux
+}
+
+func writeJSON(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(body)
+}
+
+// waitResumed blocks while topic is paused.
+func (r *Registry) waitResumed(ctx context.Context, topic string) error {
+	r.mu.Lock()
+	m := r.topics[topic]
+	paused, resume := m.Paused, m.resume
+	r.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resume:
+		return nil
+	}
+}
+
+func (r *Registry) observe(topic string, msg kafka.Message) {
+	r.update(topic, func(m *topicState) {
+		lag := msg.HighWaterMark - msg.Offset - 1
+		if lag < 0 {
+			lag = 0
+		}
+		m.partitionLag[msg.Partition] = lag
+		m.Lag = 0
+		for _, partitionLag := range m.partitionLag {
+			m.Lag += partitionLag
+		}
+	})
+}
+
+func (r *Registry) processed(topic string, took time.Duration) {
+	r.update(topic, func(m *topicState) {
+		now := time.Now()
+		m.Processed++
+		m.totalProcessing += took
+		m.AvgProcessingMs = float64(m.totalProcessing.Microseconds()) / float64(m.Processed) / 1000
+		m.LastProcessedAt = &now
+	})
+}
+
+func (r *Registry) failed(topic string, err error) {
+	r.update(topic, func(m *topicState) {
+		now := time.Now()
+		m.Failed++
+		m.LastError = err.Error()
+		m.LastErrorAt = &now
+	})
+}
+
+func (r *Registry) retried(topic string) {
+	r.update(topic, func(m *topicState) { m.Retried++ })
+}
+
+func (r *Registry) deadLettered(topic string) {
+	r.update(topic, func(m *topicState) { m.DeadLettered++ })
+}
+
+func (r *Registry) update(topic string, fn func(*topicState)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.topics[topic]
+	if !ok {
+		return false
+	}
+	fn(m)
+	return true
+}
@@ -0,0 +1,86 @@
+package customerservice
+
+import (
+	"boiler-plate/pkg/httpclient"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderAPIKey carries Config.APIKey on every request.
+	HeaderAPIKey = "x-api-key"
+
+	// idPlaceholder is replaced by the customer ID in Config.DetailInvestorURL.
+	// Without it the ID is appended as the last path segment.
+	idPlaceholder = "{id}"
+)
+
+// ErrNotFound is returned when the customer service does not know the customer.
+var ErrNotFound = errors.New("customerservice: customer not found")
+
+// InvestorDetail is the investor profile held by the customer service.
+type InvestorDetail struct {
+	CustomerID         int64     `json:"customer_id"`
+	Fullname           string    `json:"fullname"`
+	Email              string    `json:"email"`
+	PhoneNumber        string    `json:"phone_number"`
+	InvestorCategoryID *int64    `json:"investor_category_id"`
+	InvestorCategory   string    `json:"investor_category"`
+	Status             string    `json:"status"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type Config struct {
+	DetailInvestorURL string
+	APIKey            string
+}
+
+// Client reads investor data from the customer service.
+type Client struct {
+	httpClient httpclient.Client
+	config     *Config
+}
+
+func New(httpClient httpclient.Client, config *Config) *Client {
+	return &Client{httpClient: httpClient, config: config}
+}
+
+// GetInvestorDetail returns the investor profile of customerID.
+func (c *Client) GetInvestorDetail(ctx context.Context, customerID int64) (*InvestorDetail, error) {
+	var response struct {
+		StatusCode int             `json:"status_code"`
+		Message    any             `json:"message"`
+		Data       *InvestorDetail `json:"data"`
+	}
+	headers := map[string]string{
+		"Accept":     "application/json",
+		HeaderAPIKey: c.config.APIKey,
+	}
+	statusCode, err := c.httpClient.Get(c.detailURL(customerID), headers, &response)
+	if statusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("customerservice: get investor %d (status %d): %w", customerID, statusCode, err)
+	}
+	if response.Data == nil {
+		return nil, ErrNotFound
+	}
+	if response.Data.CustomerID == 0 {
+		response.Data.CustomerID = customerID
+	}
+	return response.Data, nil
+}
+
+func (c *Client) detailURL(customerID int64) string {
+	id := strconv.FormatInt(customerID, 10)
+	if strings.Contains(c.config.DetailInvestorURL, idPlaceholder) {
+		return strings.ReplaceAll(c.config.DetailInvestorURL, idPlaceholder, id)
+	}
+	return strings.TrimSuffix(c.config.DetailInvestorURL, "/") + "/" + id
+}
@@ -1,6 +1,10 @@
 package errs
 
-import "gorm.io/gorm"
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
 
 type ErrorType uint64
 
@@ -45,7 +49,7 @@ func (msg errCustom) GetErrorType() ErrorType {
 }
 
 func (msg errCustom) IsErrNoRows() bool {
-	return msg.Err == gorm.ErrRecordNotFound
+	return errors.Is(msg.Err, gorm.ErrRecordNotFound)
 }
 
 func (msg *errCustom) IsType(flags ErrorType) bool {
@@ -64,3 +68,8 @@ func (msg *errCustom) GetErrorDebugResponse() *ErrorDebugResponse {
 
 	return &e
 }
+
+// Unwrap lets errors.Is and errors.As see the wrapped error.
+func (msg *errCustom) Unwrap() error {
+	return msg.Err
+}
@@ -0,0 +1,174 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Bounds a newer schema may not tighten, raising a lower bound or lowering an
+// upper one rejects data the older schema accepted.
+var (
+	lowerBounds = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	upperBounds = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+)
+
+// compatible lists why data valid for a consumer of older may not be
+// produced anymore under newer. Checked recursively on properties and items:
+// properties cannot be removed, required properties stay required, types may
+// only widen, a closed object stays closed, bounds and enum may only loosen
+// and pattern, format and const may not be added or changed. Combinators
+// ($ref, allOf, anyOf, oneOf, not, if), multipleOf, uniqueItems and
+// patternProperties are not checked.
+func compatible(at string, older, newer interface{}) []string {
+	oldSchema, _ := older.(map[string]interface{})
+	newSchema, _ := newer.(map[string]interface{})
+	if oldSchema == nil || newSchema == nil {
+		return nil
+	}
+	var problems []string
+	name := at
+	if name == "" {
+		name = "(root)"
+	}
+
+	if oldTypes, ok := types(oldSchema); ok {
+		newTypes, restricted := types(newSchema)
+		for t := range oldTypes {
+			if restricted && !newTypes[t] {
+				problems = append(problems, fmt.Sprintf("%s no longer accepts type %s", name, t))
+			}
+		}
+	}
+
+	problems = append(problems, narrowed(name, oldSchema, newSchema)...)
+
+	oldProperties, _ := oldSchema["properties"].(map[string]interface{})
+	newProperties, _ := newSchema["properties"].(map[string]interface{})
+	for property, oldProperty := range oldProperties {
+		newProperty, ok := newProperties[property]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s was removed", at, property))
+			continue
+		}
+		problems = append(problems, compatible(at+"."+property, oldProperty, newProperty)...)
+	}
+
+	newRequired := map[string]bool{}
+	for _, property := range list(newSchema["required"]) {
+		newRequired[property] = true
+	}
+	for _, property := range list(oldSchema["required"]) {
+		if !newRequired[property] {
+			problems = append(problems, fmt.Sprintf("%s.%s is no longer required", at, property))
+		}
+	}
+
+	if open, ok := oldSchema["additionalProperties"].(bool); ok && !open {
+		for property := range newProperties {
+			if _, ok := oldProperties[property]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is not allowed by the closed object", at, property))
+			}
+		}
+	}
+
+	if oldItems, ok := oldSchema["items"]; ok {
+		problems = append(problems, compatible(at+"[]", oldItems, newSchema["items"])...)
+	}
+	return problems
+}
+
+// narrowed lists the constraints of newer that are stricter than in older.
+func narrowed(name string, older, newer map[string]interface{}) []string {
+	var problems []string
+	for _, keyword := range lowerBounds {
+		if bound, ok := number(newer[keyword]); ok {
+			if previous, had := number(older[keyword]); !had || bound > previous {
+				problems = append(problems, fmt.Sprintf("%s %s was raised to %v", name, keyword, newer[keyword]))
+			}
+		}
+	}
+	for _, keyword := range upperBounds {
+		if bound, ok := number(newer[keyword]); ok {
+			if previous, had := number(older[keyword]); !had || bound < previous {
+				problems = append(problems, fmt.Sprintf("%s %s was lowered to %v", name, keyword, newer[keyword]))
+			}
+		}
+	}
+
+	if newEnum, ok := newer["enum"].([]interface{}); ok {
+		oldEnum, had := older["enum"].([]interface{})
+		if !had {
+			problems = append(problems, fmt.Sprintf("%s enum was added", name))
+		}
+		for _, value := range oldEnum {
+			if !contains(newEnum, value) {
+				problems = append(problems, fmt.Sprintf("%s enum no longer accepts %v", name, value))
+			}
+		}
+	}
+
+	for _, keyword := range []string{"pattern", "format", "const"} {
+		value, ok := newer[keyword]
+		if !ok {
+			continue
+		}
+		if previous, had := older[keyword]; !had || !reflect.DeepEqual(previous, value) {
+			problems = append(problems, fmt.Sprintf("%s %s was changed to %v", name, keyword, value))
+		}
+	}
+	return problems
+}
+
+// number returns the value of a numeric keyword, schemas are decoded with
+// json.Number.
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// types returns the set of JSON types of a schema, false when unrestricted.
+func types(schema map[string]interface{}) (map[string]bool, bool) {
+	result := map[string]bool{}
+	switch t := schema["type"].(type) {
+	case string:
+		result[t] = true
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				result[s] = true
+			}
+		}
+	default:
+		return nil, false
+	}
+	// every integer is also a number
+	if result["number"] {
+		result["integer"] = true
+	}
+	return result, true
+}
+
+func list(value interface{}) []string {
+	items, _ := value.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		name     string
+		older    string
+		newer    string
+		problems []string
+	}{
+		{
+			name:  "unchanged",
+			older: `{"type": "object", "properties": {"id": {"type": "integer", "minimum": 1}}, "required": ["id"]}`,
+			newer: `{"type": "object", "properties": {"id": {"type": "integer", "minimum": 1}}, "required": ["id"]}`,
+		},
+		{
+			name:  "optional property added",
+			older: `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+			newer: `{"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}`,
+		},
+		{
+			name:     "property removed",
+			older:    `{"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}`,
+			newer:    `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+			problems: []string{".name was removed"},
+		},
+		{
+			name:     "no longer required",
+			older:    `{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}`,
+			newer:    `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+			problems: []string{".id is no longer required"},
+		},
+		{
+			name:  "type widened",
+			older: `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+			newer: `{"type": "object", "properties": {"id": {"type": ["integer", "string"]}}}`,
+		},
+		{
+			name:  "integer widened to number",
+			older: `{"type": "integer"}`,
+			newer: `{"type": "number"}`,
+		},
+		{
+			name:     "type narrowed",
+			older:    `{"type": "object", "properties": {"id": {"type": ["integer", "string"]}}}`,
+			newer:    `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+			problems: []string{".id no longer accepts type string"},
+		},
+		{
+			name:     "closed object gets a property",
+			older:    `{"type": "object", "properties": {"id": {}}, "additionalProperties": false}`,
+			newer:    `{"type": "object", "properties": {"id": {}, "name": {}}, "additionalProperties": false}`,
+			problems: []string{".name is not allowed by the closed object"},
+		},
+		{
+			name:     "nested items",
+			older:    `{"type": "array", "items": {"type": "object", "properties": {"id": {"type": "integer"}}}}`,
+			newer:    `{"type": "array", "items": {"type": "object", "properties": {"id": {"type": "string"}}}}`,
+			problems: []string{"[].id no longer accepts type integer"},
+		},
+		{
+			name:  "bounds loosened",
+			older: `{"type": "string", "minLength": 2, "maxLength": 10}`,
+			newer: `{"type": "string", "minLength": 1, "maxLength": 20}`,
+		},
+		{
+			name:     "minimum raised",
+			older:    `{"type": "integer", "minimum": 1}`,
+			newer:    `{"type": "integer", "minimum": 10}`,
+			problems: []string{"(root) minimum was raised to 10"},
+		},
+		{
+			name:     "maximum added",
+			older:    `{"type": "integer"}`,
+			newer:    `{"type": "integer", "maximum": 100}`,
+			problems: []string{"(root) maximum was lowered to 100"},
+		},
+		{
+			name:     "maxLength lowered",
+			older:    `{"type": "object", "properties": {"name": {"type": "string", "maxLength": 150}}}`,
+			newer:    `{"type": "object", "properties": {"name": {"type": "string", "maxLength": 100}}}`,
+			problems: []string{".name maxLength was lowered to 100"},
+		},
+		{
+			name:     "minItems raised",
+			older:    `{"type": "array"}`,
+			newer:    `{"type": "array", "minItems": 1}`,
+			problems: []string{"(root) minItems was raised to 1"},
+		},
+		{
+			name:  "enum extended",
+			older: `{"type": "string", "enum": ["update", "rollback"]}`,
+			newer: `{"type": "string", "enum": ["initial", "update", "rollback"]}`,
+		},
+		{
+			name:     "enum value removed",
+			older:    `{"type": "string", "enum": ["initial", "update", "rollback"]}`,
+			newer:    `{"type": "string", "enum": ["update", "rollback"]}`,
+			problems: []string{"(root) enum no longer accepts initial"},
+		},
+		{
+			name:     "enum added",
+			older:    `{"type": "string"}`,
+			newer:    `{"type": "string", "enum": ["update"]}`,
+			problems: []string{"(root) enum was added"},
+		},
+		{
+			name:  "pattern removed",
+			older: `{"type": "string", "pattern": "^[0-9]+$"}`,
+			newer: `{"type": "string"}`,
+		},
+		{
+			name:     "pattern changed",
+			older:    `{"type": "string", "pattern": "^[0-9]+$"}`,
+			newer:    `{"type": "string", "pattern": "^[0-9]{6}$"}`,
+			problems: []string{"(root) pattern was changed to ^[0-9]{6}$"},
+		},
+		{
+			name:     "format added",
+			older:    `{"type": "object", "properties": {"at": {"type": "string"}}}`,
+			newer:    `{"type": "object", "properties": {"at": {"type": "string", "format": "date-time"}}}`,
+			problems: []string{".at format was changed to date-time"},
+		},
+		{
+			name:     "const added",
+			older:    `{"type": "integer"}`,
+			newer:    `{"type": "integer", "const": 1}`,
+			problems: []string{"(root) const was changed to 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older, err := decodeJSON([]byte(tt.older))
+			require.NoError(t, err)
+			newer, err := decodeJSON([]byte(tt.newer))
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.problems, compatible("", older, newer))
+		})
+	}
+}
+
+func TestRegisterRejectsIncompatibleVersions(t *testing.T) {
+	r := NewRegistry()
+	v1 := `{"type": "object", "properties": {"action": {"type": "string", "enum": ["update", "rollback"]}}}`
+	require.NoError(t, r.Register("settings.changed", 1, []byte(v1)))
+	require.NoError(t, r.Register("settings.changed", 1, []byte(v1)), "registering the same schema again is a no-op")
+
+	changed := `{"type": "object", "properties": {"action": {"type": "string"}}}`
+	assert.ErrorIs(t, r.Register("settings.changed", 1, []byte(changed)), ErrSchemaChanged)
+
+	narrowed := `{"type": "object", "properties": {"action": {"type": "string", "enum": ["update"]}}}`
+	assert.ErrorIs(t, r.Register("settings.changed", 2, []byte(narrowed)), ErrIncompatible)
+
+	v3 := `{"type": "object", "properties": {"action": {"type": "string", "enum": ["initial", "update", "rollback"]}}}`
+	require.NoError(t, r.Register("settings.changed", 3, []byte(v3)))
+	// a version inserted between two others is checked against the next one
+	assert.ErrorIs(t, r.Register("settings.changed", 2, []byte(changed)), ErrIncompatible)
+}
@@ -0,0 +1,46 @@
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope wraps every event published on Kafka. Data is validated against
+// the schema registered for EventType and Version.
+type Envelope struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Version    int             `json:"event_version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Producer   string          `json:"producer"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewEnvelope marshals data into a new envelope with a random event ID.
+func NewEnvelope(eventType string, version int, data interface{}, producer, requestID string) (*Envelope, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		EventID:    uuid.NewString(),
+		EventType:  eventType,
+		Version:    version,
+		OccurredAt: time.Now().UTC(),
+		RequestID:  requestID,
+		Producer:   producer,
+		Data:       payload,
+	}, nil
+}
+
+// IsEnvelope reports whether payload is an envelope rather than a bare event
+// from a producer that does not wrap its events yet.
+func IsEnvelope(payload []byte) bool {
+	var probe struct {
+		EventType *string `json:"event_type"`
+	}
+	return json.Unmarshal(payload, &probe) == nil && probe.EventType != nil
+}
@@ -0,0 +1,217 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var (
+	ErrUnknownSchema   = errors.New("event: unknown schema")
+	ErrSchemaChanged   = errors.New("event: registered schema cannot be changed")
+	ErrIncompatible    = errors.New("event: incompatible schema")
+	ErrInvalidPayload  = errors.New("event: payload does not match schema")
+	ErrInvalidEnvelope = errors.New("event: invalid envelope")
+)
+
+type schema struct {
+	document interface{}
+	compiled *jsonschema.Schema
+}
+
+// Registry holds the JSON Schema of every event type and version. A new
+// version must stay compatible with the version before it, see Register.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]map[int]*schema
+}
+
+func NewRegistry() *Registry {
+	return &Registry{schemas: map[string]map[int]*schema{}}
+}
+
+// Register adds the schema of eventType at version. Registering the same
+// schema again is a no-op, a different one for an existing version returns
+// ErrSchemaChanged. The schema must be compatible with the closest versions
+// around it: no property may be removed, stop being required, change type or
+// get stricter constraints, see compatible.
+func (r *Registry) Register(eventType string, version int, document []byte) error {
+	if eventType == "" || version < 1 {
+		return fmt.Errorf("event: invalid schema id %s v%d", eventType, version)
+	}
+	decoded, err := decodeJSON(document)
+	if err != nil {
+		return fmt.Errorf("event: decode schema %s v%d: %w", eventType, version, err)
+	}
+	url := fmt.Sprintf("event://%s/v%d.json", eventType, version)
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, bytes.NewReader(document)); err != nil {
+		return fmt.Errorf("event: load schema %s v%d: %w", eventType, version, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("event: compile schema %s v%d: %w", eventType, version, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions, ok := r.schemas[eventType]
+	if !ok {
+		versions = map[int]*schema{}
+		r.schemas[eventType] = versions
+	}
+	if existing, ok := versions[version]; ok {
+		if reflect.DeepEqual(existing.document, decoded) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s v%d", ErrSchemaChanged, eventType, version)
+	}
+
+	previous, next := 0, 0
+	for v := range versions {
+		if v < version && v > previous {
+			previous = v
+		}
+		if v > version && (next == 0 || v < next) {
+			next = v
+		}
+	}
+	if previous != 0 {
+		if problems := compatible("", versions[previous].document, decoded); len(problems) > 0 {
+			return fmt.Errorf("%w: %s v%d to v%d: %s",
+				ErrIncompatible, eventType, previous, version, strings.Join(problems, "; "))
+		}
+	}
+	if next != 0 {
+		if problems := compatible("", decoded, versions[next].document); len(problems) > 0 {
+			return fmt.Errorf("%w: %s v%d to v%d: %s",
+				ErrIncompatible, eventType, version, next, strings.Join(problems, "; "))
+		}
+	}
+
+	versions[version] = &schema{document: decoded, compiled: compiled}
+	return nil
+}
+
+// LoadFS registers every "<event type>/v<version>.json" file of fsys, lowest
+// version first.
+func (r *Registry) LoadFS(fsys fs.FS) error {
+	type file struct {
+		name      string
+		eventType string
+		version   int
+	}
+	var files []file
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		base := path.Base(name)
+		version, convErr := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(base, "v"), ".json"))
+		if !strings.HasPrefix(base, "v") || !strings.HasSuffix(base, ".json") || convErr != nil {
+			return fmt.Errorf("event: unexpected schema file %s", name)
+		}
+		files = append(files, file{name: name, eventType: path.Dir(name), version: version})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].eventType != files[j].eventType {
+			return files[i].eventType < files[j].eventType
+		}
+		return files[i].version < files[j].version
+	})
+	for _, f := range files {
+		document, err := fs.ReadFile(fsys, f.name)
+		if err != nil {
+			return err
+		}
+		if err := r.Register(f.eventType, f.version, document); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Latest returns the highest registered version of eventType.
+func (r *Registry) Latest(eventType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	latest := 0
+	for v := range r.schemas[eventType] {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest, latest != 0
+}
+
+// Validate checks data against the schema of eventType at version.
+func (r *Registry) Validate(eventType string, version int, data []byte) error {
+	r.mu.RLock()
+	s, ok := r.schemas[eventType][version]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s v%d", ErrUnknownSchema, eventType, version)
+	}
+
+	decoded, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidPayload, eventType, version, err)
+	}
+	if err := s.compiled.Validate(decoded); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidPayload, eventType, version, err)
+	}
+	return nil
+}
+
+// Encode validates the data of envelope and returns the message value.
+func (r *Registry) Encode(envelope *Envelope) ([]byte, error) {
+	if err := r.Validate(envelope.EventType, envelope.Version, envelope.Data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+// Decode parses a message value and validates its data against the schema
+// named in the envelope.
+func (r *Registry) Decode(value []byte) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if envelope.EventID == "" || envelope.EventType == "" || envelope.Version < 1 {
+		return nil, fmt.Errorf("%w: event_id, event_type and event_version are required", ErrInvalidEnvelope)
+	}
+	if err := r.Validate(envelope.EventType, envelope.Version, envelope.Data); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// decodeJSON keeps numbers as json.Number as the validator expects.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
@@ -1,26 +1,138 @@
 package exception
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"boiler-plate/pkg/errs"
+)
+
 // Code is a type alias for string, representing the error code of an exception.
 type Code string
 
 // Predefined error codes.
 const (
-	InvalidArgumentCode  Code = "INVALID_ARGUMENT"  // Represents an invalid argument error.
-	NotFoundCode         Code = "NOT_FOUND"         // Represents a not found error.
-	AlreadyExistsCode    Code = "ALREADY_EXISTS"    // Represents an already exists error.
-	PermissionDeniedCode Code = "PERMISSION_DENIED" // Represents a permission denied error.
-	UnauthenticatedCode  Code = "UNAUTHENTICATED"   // Represents an unauthenticated error.
-	InternalErrorCode    Code = "INTERNAL"          // Represents an internal error.
+	InvalidArgumentCode   Code = "INVALID_ARGUMENT"   // Represents an invalid argument error.
+	NotFoundCode          Code = "NOT_FOUND"          // Represents a not found error.
+	AlreadyExistsCode     Code = "ALREADY_EXISTS"     // Represents an already exists error.
+	PermissionDeniedCode  Code = "PERMISSION_DENIED"  // Represents a permission denied error.
+	UnauthenticatedCode   Code = "UNAUTHENTICATED"    // Represents an unauthenticated error.
+	InternalErrorCode     Code = "INTERNAL"           // Represents an internal error.
+	ResourceExhaustedCode Code = "RESOURCE_EXHAUSTED" // Represents a rate or attempt limit error.
 )
 
-// Exception is a struct to represent exception/error from service.
+// Exception is the error returned by services and rendered by the run
+// actions of the base handler.
 // Code is the error code of the exception.
-// Message is the error message of the exception.
-// Error is the original error that caused the exception, if any.
+// Status is the HTTP status, derived from Code when zero.
+// ResponseCode is the business response code like "4010000", derived from
+// the HTTP status when empty. Exceptions with an explicit ResponseCode belong
+// to the SNAP B2B API and keep its {responseCode, responseMessage} body.
+// Message is the error message shown to the user.
+// Fields are the per field messages of a validation error.
+// Cause is the original error that caused the exception, if any.
+// Stack is where an internal error happened, only shown in debug mode.
 type Exception struct {
-	Code    Code
-	Message any
-	Error   error
+	Code         Code
+	Status       int
+	ResponseCode string
+	Message      any
+	Fields       map[string]string
+	Cause        error
+	Stack        string
+}
+
+// Error implements error so an Exception can travel through error returns.
+func (e *Exception) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%v: %v", e.Message, e.Cause)
+	}
+	return fmt.Sprint(e.Message)
+}
+
+func (e *Exception) Unwrap() error {
+	return e.Cause
+}
+
+// HTTPStatus returns Status or the status matching Code.
+func (e *Exception) HTTPStatus() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	switch e.Code {
+	case InvalidArgumentCode:
+		return http.StatusBadRequest
+	case NotFoundCode:
+		return http.StatusNotFound
+	case AlreadyExistsCode:
+		return http.StatusConflict
+	case PermissionDeniedCode:
+		return http.StatusForbidden
+	case UnauthenticatedCode:
+		return http.StatusUnauthorized
+	case ResourceExhaustedCode:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// MessageString returns Message as text, a list of messages is joined.
+func (e *Exception) MessageString() string {
+	if messages, ok := e.Message.([]string); ok {
+		return strings.Join(messages, ", ")
+	}
+	return fmt.Sprint(e.Message)
+}
+
+// BusinessCode returns ResponseCode or the HTTP status followed by a zero
+// service and case code, e.g. 4040000.
+func (e *Exception) BusinessCode() string {
+	if e.ResponseCode != "" {
+		return e.ResponseCode
+	}
+	return StatusResponseCode(e.HTTPStatus())
+}
+
+// StatusResponseCode returns the business response code of an HTTP status
+// with a zero service and case code.
+func StatusResponseCode(status int) string {
+	return fmt.Sprintf("%d0000", status)
+}
+
+// IsSNAP reports whether the exception renders in the SNAP body.
+func (e *Exception) IsSNAP() bool {
+	return e.ResponseCode != ""
+}
+
+// WithResponseCode sets the SNAP business response code.
+func (e *Exception) WithResponseCode(code string) *Exception {
+	e.ResponseCode = code
+	return e
+}
+
+// From turns any error into an Exception. Exceptions are returned as is,
+// record not found becomes NotFound and anything else an Internal error
+// keeping the stack of errs.Wrap when there is one.
+func From(err error) *Exception {
+	if err == nil {
+		return nil
+	}
+	var exc *Exception
+	if errors.As(err, &exc) {
+		return exc
+	}
+	var wrapped errs.Error
+	if errors.As(err, &wrapped) {
+		if wrapped.IsErrNoRows() {
+			return &Exception{Code: NotFoundCode, Message: "data not found", Cause: err}
+		}
+		return &Exception{Code: InternalErrorCode, Message: "internal server error", Cause: err, Stack: wrapped.GetStack()}
+	}
+	return internal("internal server error", err, 3)
 }
 
 // InvalidArgument creates a new Exception with the InvalidArgumentCode error code.
@@ -63,13 +175,28 @@ func Unauthenticated(message any) *Exception {
 	}
 }
 
+// ResourceExhausted creates a new Exception with the ResourceExhaustedCode error code.
+func ResourceExhausted(message any) *Exception {
+	return &Exception{
+		Code:    ResourceExhaustedCode,
+		Message: message,
+	}
+}
+
 // Internal creates a new Exception with the InternalErrorCode error code.
-// The original error that caused the exception is also included.
+// The original error that caused the exception and the stack are also
+// included.
 func Internal(message any, err error) *Exception {
+	return internal(message, err, 3)
+}
+
+func internal(message any, err error, skip int) *Exception {
+	stack, _ := errs.StackAndFile(skip)
 	return &Exception{
 		Code:    InternalErrorCode,
 		Message: message,
-		Error:   err,
+		Cause:   err,
+		Stack:   stack,
 	}
 }
 
@@ -80,3 +207,17 @@ func Conflict(message any) *Exception {
 		Message: message,
 	}
 }
+
+// Validation creates a new Exception with the InvalidArgumentCode error code.
+// The field errors returned by xvalidator are kept in Fields and listed as a
+// sorted message array.
+func Validation(fieldErrors map[string]string) *Exception {
+	messages := make([]string, 0, len(fieldErrors))
+	for _, message := range fieldErrors {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	exc := InvalidArgument(messages)
+	exc.Fields = fieldErrors
+	return exc
+}
@@ -0,0 +1,95 @@
+package exception
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SNAP creates an Exception of the SNAP B2B API, rendered as
+// {responseCode, responseMessage}.
+func SNAP(status int, responseCode, message string) *Exception {
+	return &Exception{
+		Code:         codeForStatus(status),
+		Status:       status,
+		ResponseCode: responseCode,
+		Message:      message,
+	}
+}
+
+// RequiredField is a missing SNAP header or field.
+func RequiredField(field string) *Exception {
+	return SNAP(http.StatusBadRequest, "4000000", fmt.Sprintf("The %s field is required.", field))
+}
+
+// InvalidFieldFormat is a SNAP header or field that does not parse.
+func InvalidFieldFormat(field string) *Exception {
+	return SNAP(http.StatusBadRequest, "4000000", "Invalid Field Format "+field)
+}
+
+// InvalidFieldLength is a SNAP header or field longer than max.
+func InvalidFieldLength(field string, max int) *Exception {
+	return SNAP(http.StatusBadRequest, "4000000", fmt.Sprintf(
+		"The field %s must be a string or array type with a maximum length of '%d'.", field, max,
+	))
+}
+
+// InvalidJSON is a request body that cannot be bound.
+func InvalidJSON(message string) *Exception {
+	return SNAP(http.StatusBadRequest, "400", message)
+}
+
+// PayloadTooLarge is a request body longer than max bytes.
+func PayloadTooLarge(max int64) *Exception {
+	return SNAP(http.StatusRequestEntityTooLarge, "4130000", fmt.Sprintf("Request body must not exceed %d bytes.", max))
+}
+
+func RequiredGrantType() *Exception {
+	return SNAP(http.StatusBadRequest, "4007302", "Bad Request. The grantType field is required.")
+}
+
+func UnsupportedGrantType() *Exception {
+	return SNAP(http.StatusBadRequest, "4007300", "grant_type must be set to client_credentials")
+}
+
+func InvalidClientID() *Exception {
+	return SNAP(http.StatusBadRequest, "400", "invalid clientid")
+}
+
+func InvalidClientSecret() *Exception {
+	return SNAP(http.StatusBadRequest, "4010000", "Invalid Client Secret")
+}
+
+func InvalidPublicKey() *Exception {
+	return SNAP(http.StatusUnauthorized, "4010000", "Invalid Public Key")
+}
+
+func InvalidSignature() *Exception {
+	return SNAP(http.StatusUnauthorized, "4017300", "Invalid Token (B2B)")
+}
+
+func RequiredBearer() *Exception {
+	return SNAP(http.StatusUnauthorized, "4000002", "Bearer authorization is required")
+}
+
+func InvalidAccessToken() *Exception {
+	return SNAP(http.StatusUnauthorized, "4010001", "Access Token Invalid")
+}
+
+func codeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return InvalidArgumentCode
+	case http.StatusUnauthorized:
+		return UnauthenticatedCode
+	case http.StatusForbidden:
+		return PermissionDeniedCode
+	case http.StatusNotFound:
+		return NotFoundCode
+	case http.StatusConflict:
+		return AlreadyExistsCode
+	case http.StatusTooManyRequests:
+		return ResourceExhaustedCode
+	default:
+		return InternalErrorCode
+	}
+}
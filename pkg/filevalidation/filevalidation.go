@@ -1,13 +1,14 @@
 package filevalidation
 
 import (
+	"boiler-plate/pkg/storage"
 	"bufio"
+	"context"
 	"encoding/base64"
 	"errors"
 	"github.com/gabriel-vasile/mimetype"
 	"io"
 	"log/slog"
-	"os"
 )
 
 func ValidateImage(base64String string, maxsize int64) (string, error) {
@@ -36,9 +37,9 @@ func ValidateImage(base64String string, maxsize int64) (string, error) {
 	return mimeType.Extension(), nil
 }
 
-func LoadImage64(filePath string, filename string) (string, error) {
-	// Open file on disk.
-	file, err := os.Open(filePath + filename)
+func LoadImage64(ctx context.Context, store storage.Storage, filename string) (string, error) {
+	// Open file in storage.
+	file, _, err := store.Get(ctx, filename)
 	if err != nil {
 		slog.Error(err.Error())
 		return "", err
@@ -1,65 +1,97 @@
 package httputils
 
-import "boiler-plate/pkg/exception"
+import (
+	"encoding/json"
 
-// ErrorResponse is a struct to represent error http response
-type ErrorResponse struct {
-	StatusCode  int    `json:"status_code"`
-	Message     any    `json:"message"`
+	"boiler-plate/pkg/exception"
+)
+
+// Response formats selected by RESPONSE_FORMAT or the X-Response-Format
+// header.
+const (
+	FormatEnvelope = "envelope"
+	FormatLegacy   = "legacy"
+)
+
+// Envelope is the body of every API response.
+type Envelope struct {
+	Status       int               `json:"status"`
+	ResponseCode string            `json:"response_code"`
+	Message      string            `json:"message"`
+	Data         any               `json:"data"`
+	RequestID    string            `json:"request_id"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Errors       map[string]string `json:"errors,omitempty"`
+	// DetailError and StackTrace are only filled in debug mode
 	DetailError string `json:"error,omitempty"`
+	StackTrace  string `json:"stack_trace,omitempty"`
 }
 
-func (r *ErrorResponse) Error() string {
-	return r.DetailError
+// Meta is the pagination of a list response.
+type Meta struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalRows  int `json:"total_rows"`
+	TotalPages int `json:"total_pages"`
 }
 
-// GenErrorResponse is a function to generate error http response
-func GenErrorResponse(statusCode int, message any, err error) *ErrorResponse {
-	if err == nil {
-		return &ErrorResponse{
-			StatusCode: statusCode,
-			Message:    message,
-		}
+// GenErrorEnvelope is a function to generate the envelope of an exception
+func GenErrorEnvelope(exc *exception.Exception, requestID string) *Envelope {
+	env := &Envelope{
+		Status:       exc.HTTPStatus(),
+		ResponseCode: exc.BusinessCode(),
+		Message:      exc.MessageString(),
+		RequestID:    requestID,
+		Errors:       exc.Fields,
 	}
-	return &ErrorResponse{
-		StatusCode:  statusCode,
-		Message:     message,
-		DetailError: err.Error(),
+	if exc.Cause != nil {
+		env.DetailError = exc.Cause.Error()
 	}
+	return env
 }
 
-// GenErrorResponseException is a function to generate error response from exception
-func GenErrorResponseException(exc *exception.Exception) *ErrorResponse {
-	switch exc.Code {
-	case exception.InvalidArgumentCode:
-		return GenErrorResponse(400, exc.Message, exc.Error)
-	case exception.NotFoundCode:
-		return GenErrorResponse(404, exc.Message, exc.Error)
-	case exception.AlreadyExistsCode:
-		return GenErrorResponse(409, exc.Message, exc.Error)
-	case exception.PermissionDeniedCode:
-		return GenErrorResponse(403, exc.Message, exc.Error)
-	case exception.UnauthenticatedCode:
-		return GenErrorResponse(401, exc.Message, exc.Error)
-	case exception.InternalErrorCode:
-		return GenErrorResponse(500, exc.Message, exc.Error)
-	default:
-		return GenErrorResponse(500, exc.Message, exc.Error)
+// Legacy returns the envelope in the legacy responseCode/responseMessage
+// shape, responseCode is the HTTP status as it always was. The fields of an
+// object Data are merged at the top level, any other Data is kept under
+// "data" and Meta under "pagination".
+func (e *Envelope) Legacy() (map[string]any, error) {
+	body := map[string]any{}
+	if e.Data != nil {
+		raw, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			body = map[string]any{"data": e.Data}
+		}
+		if body == nil {
+			body = map[string]any{}
+		}
 	}
+	body["responseCode"] = e.Status
+	body["responseMessage"] = e.Message
+	if e.Meta != nil {
+		body["pagination"] = e.Meta
+	}
+	if e.Errors != nil {
+		body["errors"] = e.Errors
+	}
+	if e.DetailError != "" {
+		body["error"] = e.DetailError
+	}
+	if e.StackTrace != "" {
+		body["stack_trace"] = e.StackTrace
+	}
+	return body, nil
 }
 
-type ExceptionResponse struct {
-	StatusCode int `json:"status_code"`
-	Message    any `json:"message"`
-}
-
-type SuccessResponse struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-}
-
-type DataSuccessResponse struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-	Data       any    `json:"data"`
+// SNAP returns the legacy shape with the seven digit business code of the
+// SNAP B2B API as responseCode, e.g. "4017300".
+func (e *Envelope) SNAP() (map[string]any, error) {
+	body, err := e.Legacy()
+	if err != nil {
+		return nil, err
+	}
+	body["responseCode"] = e.ResponseCode
+	return body, nil
 }
@@ -1,70 +1,63 @@
 package jwt
 
+import (
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
 var UserRoleCustomer = "cpm_customer"
 var UserRoleAdmin = "cpm_admin"
 var UserRoleSales = "cpm_sales"
 
-//func AuthJWT(
-//	auth *logindomain.Users,
-//	roles *[]logindomain.RolePermission,
-//	resSales *logindomain.AccountSales,
-//	resRole *logindomain.Role,
-//	JWTSecretAccessToken []byte,
-//) (string, error) {
-//	id := uuid.New()
-//	expTime := time.Now().Add(time.Minute * 60)
-//	var token *jwt.Token
-//
-//	// Admin
-//	if resRole.Name == UserRoleAdmin {
-//		claims := &logindomain.JwtAdmin{
-//			Sub:        auth.ID,
-//			Name:       auth.Name,
-//			Email:      auth.Email,
-//			RoleId:     auth.RoleId,
-//			Roles:      []string{resRole.Name},
-//			Permission: []string{},
-//			RegisteredClaims: jwt.RegisteredClaims{
-//				Issuer:    "login",
-//				ExpiresAt: jwt.NewNumericDate(expTime),
-//				IssuedAt:  jwt.NewNumericDate(time.Now()),
-//				NotBefore: jwt.NewNumericDate(time.Now()),
-//				ID:        id.String(),
-//			},
-//		}
-//
-//		for _, role := range *roles {
-//			claims.Permission = append(claims.Permission, role.Permission.Name)
-//		}
-//
-//		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-//	} else {
-//		// Sales
-//		claims := &logindomain.JWTSales{
-//			Sub:        auth.ID,
-//			Name:       auth.Name,
-//			Email:      resRole.Name,
-//			RoleId:     auth.RoleId,
-//			BranchId:   resSales.BranchID,
-//			SalesId:    resSales.ID,
-//			Roles:      []string{resRole.Name},
-//			Permission: []string{},
-//			RegisteredClaims: jwt.RegisteredClaims{
-//				Issuer:    "login",
-//				ExpiresAt: jwt.NewNumericDate(expTime),
-//				IssuedAt:  jwt.NewNumericDate(time.Now()),
-//				NotBefore: jwt.NewNumericDate(time.Now()),
-//				ID:        id.String(),
-//			},
-//		}
-//
-//		for _, role := range *roles {
-//			claims.Permission = append(claims.Permission, role.Permission.Name)
-//		}
-//
-//		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-//	}
-//
-//	return token.SignedString(JWTSecretAccessToken)
-//
-//}
+// Claims is the payload of the access token read back by UserRunAction.
+type Claims struct {
+	Sub        int64    `json:"sub"`
+	Name       string   `json:"name"`
+	Email      string   `json:"email"`
+	RoleId     int      `json:"role_id"`
+	Roles      []string `json:"roles"`
+	Permission []string `json:"permission"`
+	// SessionID is the auth session the token was issued for, logout
+	// revokes it.
+	SessionID int64 `json:"sid,omitempty"`
+	gojwt.RegisteredClaims
+}
+
+// AuthJWT signs claims with the current key of keys. Issuer, expiry and token
+// ID are set here.
+func AuthJWT(claims *Claims, ttl time.Duration, keys *KeySet) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = gojwt.RegisteredClaims{
+		Issuer:    "login",
+		ExpiresAt: gojwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  gojwt.NewNumericDate(now),
+		NotBefore: gojwt.NewNumericDate(now),
+		ID:        uuid.NewString(),
+	}
+
+	return keys.Sign(claims)
+}
+
+// ClientClaims is the payload of a client_credentials token. The client_id
+// claim tells it apart from user access tokens.
+type ClientClaims struct {
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	gojwt.RegisteredClaims
+}
+
+// ClientJWT signs a client_credentials token with the current key of keys.
+func ClientJWT(claims *ClientClaims, ttl time.Duration, keys *KeySet) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = gojwt.RegisteredClaims{
+		Issuer:    "client_credentials",
+		Subject:   claims.ClientID,
+		ExpiresAt: gojwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  gojwt.NewNumericDate(now),
+		NotBefore: gojwt.NewNumericDate(now),
+		ID:        uuid.NewString(),
+	}
+	return keys.Sign(claims)
+}
@@ -0,0 +1,303 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("jwt: no active signing key")
+	ErrUnknownKey   = errors.New("jwt: unknown key id")
+	ErrKeyRetired   = errors.New("jwt: key is retired")
+)
+
+// Key is one signing key. A key signs tokens between NotBefore and NotAfter
+// and keeps verifying them for the key set grace period after NotAfter, so
+// tokens issued just before a rotation stay valid until they expire.
+type Key struct {
+	ID        string
+	Algorithm string
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	secret  []byte
+	private crypto.Signer
+}
+
+// NewHMACKey returns an HS256 key. The shared secret is never published in
+// the JWKS.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: AlgHS256, secret: secret}
+}
+
+// NewKey returns an asymmetric key, the algorithm is taken from the type of
+// private: *rsa.PrivateKey (RS256), P-256 *ecdsa.PrivateKey (ES256) or
+// ed25519.PrivateKey (EdDSA).
+func NewKey(id string, private crypto.Signer) (*Key, error) {
+	key := &Key{ID: id, private: private}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("jwt: key %q: rsa keys must be at least 2048 bits", id)
+		}
+		key.Algorithm = AlgRS256
+	case *ecdsa.PrivateKey:
+		if private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("jwt: key %q: ES256 requires a P-256 key", id)
+		}
+		key.Algorithm = AlgES256
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgEdDSA
+	default:
+		return nil, fmt.Errorf("jwt: key %q: unsupported key type %T", id, private)
+	}
+	return key, nil
+}
+
+func (k *Key) method() gojwt.SigningMethod {
+	return gojwt.GetSigningMethod(k.Algorithm)
+}
+
+func (k *Key) signingKey() interface{} {
+	if k.secret != nil {
+		return k.secret
+	}
+	return k.private
+}
+
+func (k *Key) verifyKey() interface{} {
+	if k.secret != nil {
+		return k.secret
+	}
+	return k.private.Public()
+}
+
+func (k *Key) signs(now time.Time) bool {
+	return !now.Before(k.NotBefore) && (k.NotAfter.IsZero() || now.Before(k.NotAfter))
+}
+
+func (k *Key) verifies(now time.Time, grace time.Duration) bool {
+	return k.NotAfter.IsZero() || now.Before(k.NotAfter.Add(grace))
+}
+
+// KeySet holds every configured key. The newest key whose signing window
+// contains the current time signs new tokens; tokens are verified with the
+// key named by their kid header.
+type KeySet struct {
+	mu    sync.RWMutex
+	keys  []*Key
+	grace time.Duration
+	now   func() time.Time
+}
+
+// NewKeySet builds a key set. grace is how long a key keeps verifying after
+// it stops signing, normally the access token TTL.
+func NewKeySet(grace time.Duration, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{grace: grace, now: time.Now}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", key.ID)
+		}
+		seen[key.ID] = true
+		if key.method() == nil {
+			return nil, fmt.Errorf("jwt: key %q: unsupported algorithm %q", key.ID, key.Algorithm)
+		}
+	}
+	ks.keys = append(ks.keys, keys...)
+	// newest first, so the first signing key found is the latest rotation
+	sort.SliceStable(ks.keys, func(i, j int) bool {
+		return ks.keys[i].NotBefore.After(ks.keys[j].NotBefore)
+	})
+	return ks, nil
+}
+
+// SigningKey returns the key that signs tokens right now.
+func (ks *KeySet) SigningKey() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := ks.now()
+	for _, key := range ks.keys {
+		if key.signs(now) {
+			return key, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Sign signs claims with the current signing key and sets its kid header.
+func (ks *KeySet) Sign(claims gojwt.Claims) (string, error) {
+	key, err := ks.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := gojwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.signingKey())
+}
+
+// Keyfunc resolves the verification key of a token from its kid header. A
+// token without kid is only accepted by a key with an empty ID, which keeps
+// tokens from the single-secret setup valid.
+func (ks *KeySet) Keyfunc(token *gojwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok && token.Header["kid"] != nil {
+		// a kid of another type must not fall back to the legacy key
+		return nil, ErrUnknownKey
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, key := range ks.keys {
+		if key.ID != kid {
+			continue
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		if !key.verifies(ks.now(), ks.grace) {
+			return nil, ErrKeyRetired
+		}
+		return key.verifyKey(), nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Parse verifies a token and returns its claims.
+func (ks *KeySet) Parse(tokenString string) (gojwt.MapClaims, error) {
+	token, err := gojwt.ParseWithClaims(tokenString, gojwt.MapClaims{}, ks.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return token.Claims.(gojwt.MapClaims), nil
+}
+
+// JWKS returns the public keys that verify tokens now or will sign them
+// later, so downstream services learn a new key before it is used. HMAC keys
+// are never published.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := ks.now()
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if key.private == nil || !key.verifies(now, ks.grace) {
+			continue
+		}
+		set.Keys = append(set.Keys, newJWK(key))
+	}
+	return set
+}
+
+// KeyFile is the JSON document listing the keys of a key set.
+//
+//	{"keys": [{"kid": "2026-10", "alg": "RS256", "private_key_file": "/keys/2026-10.pem",
+//	  "not_before": "2026-10-01T00:00:00Z", "not_after": "2027-01-01T00:00:00Z"}]}
+//
+// HS256 keys give the secret instead of a private key file.
+type KeyFile struct {
+	Keys []struct {
+		ID             string    `json:"kid"`
+		Algorithm      string    `json:"alg"`
+		PrivateKeyFile string    `json:"private_key_file"`
+		Secret         string    `json:"secret"`
+		NotBefore      time.Time `json:"not_before"`
+		NotAfter       time.Time `json:"not_after"`
+	} `json:"keys"`
+}
+
+// LoadKeyFile reads a KeyFile and its PEM encoded private keys.
+func LoadKeyFile(path string) ([]*Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file KeyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("jwt: parse %s: %w", path, err)
+	}
+
+	keys := make([]*Key, 0, len(file.Keys))
+	for _, entry := range file.Keys {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("jwt: %s: every key needs a kid", path)
+		}
+		var key *Key
+		if entry.Algorithm == AlgHS256 {
+			if entry.Secret == "" {
+				return nil, fmt.Errorf("jwt: key %q: HS256 requires a secret", entry.ID)
+			}
+			key = NewHMACKey(entry.ID, []byte(entry.Secret))
+		} else {
+			private, err := readPrivateKey(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: key %q: %w", entry.ID, err)
+			}
+			if key, err = NewKey(entry.ID, private); err != nil {
+				return nil, err
+			}
+			if entry.Algorithm != "" && entry.Algorithm != key.Algorithm {
+				return nil, fmt.Errorf("jwt: key %q: %s key cannot sign %s", entry.ID, key.Algorithm, entry.Algorithm)
+			}
+		}
+		key.NotBefore = entry.NotBefore
+		key.NotAfter = entry.NotAfter
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// readPrivateKey parses a PKCS#8, PKCS#1 or SEC 1 PEM private key.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", private)
+	}
+	return signer, nil
+}
@@ -0,0 +1,318 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rsaKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// rotation is a key set in the middle of a rotation from the legacy secret
+// to rsa-1 and from rsa-1 to ed-1, read at now.
+func rotation(t *testing.T, now time.Time) *KeySet {
+	rsa1, err := NewKey("rsa-1", rsaKey)
+	require.NoError(t, err)
+	rsa1.NotBefore = now.Add(-48 * time.Hour)
+	rsa1.NotAfter = now.Add(-30 * time.Minute)
+
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ed1, err := NewKey("ed-1", ed)
+	require.NoError(t, err)
+	ed1.NotBefore = now.Add(-30 * time.Minute)
+
+	legacy := NewHMACKey("", []byte("legacy-secret"))
+	legacy.NotAfter = now.Add(-48 * time.Hour)
+
+	hmac := NewHMACKey("hs-1", []byte("hmac-secret"))
+	hmac.NotBefore = now.Add(-72 * time.Hour)
+	hmac.NotAfter = now.Add(-2 * time.Hour)
+
+	ks, err := NewKeySet(time.Hour, rsa1, ed1, legacy, hmac)
+	require.NoError(t, err)
+	ks.now = func() time.Time { return now }
+	return ks
+}
+
+func TestKeyfunc(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	ks := rotation(t, now)
+
+	tests := []struct {
+		name    string
+		method  gojwt.SigningMethod
+		kid     interface{}
+		now     time.Time
+		wantErr error
+		wantMsg string
+	}{
+		{name: "current key", method: gojwt.SigningMethodEdDSA, kid: "ed-1", now: now},
+		{name: "rotated key within the grace period", method: gojwt.SigningMethodRS256, kid: "rsa-1", now: now},
+		{
+			name:    "rotated key at the end of the grace period",
+			method:  gojwt.SigningMethodRS256,
+			kid:     "rsa-1",
+			now:     now.Add(30 * time.Minute),
+			wantErr: ErrKeyRetired,
+		},
+		{
+			name:    "HS256 against an RSA key",
+			method:  gojwt.SigningMethodHS256,
+			kid:     "rsa-1",
+			now:     now,
+			wantMsg: "unexpected signing method",
+		},
+		{
+			name:    "RS256 against an HMAC key",
+			method:  gojwt.SigningMethodRS256,
+			kid:     "hs-1",
+			now:     now.Add(-3 * time.Hour),
+			wantMsg: "unexpected signing method",
+		},
+		{name: "unknown kid", method: gojwt.SigningMethodRS256, kid: "rsa-3", now: now, wantErr: ErrUnknownKey},
+		{name: "kid that is not a string", method: gojwt.SigningMethodRS256, kid: 1, now: now, wantErr: ErrUnknownKey},
+		{
+			name:   "legacy token before the cutoff",
+			method: gojwt.SigningMethodHS256,
+			now:    now.Add(-48*time.Hour - time.Minute),
+		},
+		{
+			name:   "legacy token within the grace period",
+			method: gojwt.SigningMethodHS256,
+			now:    now.Add(-47*time.Hour - time.Minute),
+		},
+		{
+			name:    "legacy token after the grace period",
+			method:  gojwt.SigningMethodHS256,
+			now:     now.Add(-47 * time.Hour),
+			wantErr: ErrKeyRetired,
+		},
+		{
+			name:    "token without kid signed with RS256",
+			method:  gojwt.SigningMethodRS256,
+			now:     now.Add(-48*time.Hour - time.Minute),
+			wantMsg: "unexpected signing method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks.now = func() time.Time { return tt.now }
+			token := gojwt.New(tt.method)
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+
+			key, err := ks.Keyfunc(token)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, key)
+			case tt.wantMsg != "":
+				assert.ErrorContains(t, err, tt.wantMsg)
+				assert.Nil(t, key)
+			default:
+				assert.NoError(t, err)
+				assert.NotNil(t, key)
+			}
+		})
+	}
+}
+
+func TestParseRejectsAlgorithmConfusion(t *testing.T) {
+	ks := rotation(t, time.Now())
+	claims := gojwt.MapClaims{"sub": 1, "exp": time.Now().Add(time.Minute).Unix()}
+
+	// HS256 keyed with the published RSA public key, the classic confusion
+	public, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	forged := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "rsa-1"
+	signed, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+	require.NoError(t, err)
+	_, err = ks.Parse(signed)
+	assert.ErrorIs(t, err, gojwt.ErrTokenUnverifiable)
+	assert.ErrorContains(t, err, "unexpected signing method")
+
+	genuine := gojwt.NewWithClaims(gojwt.SigningMethodRS256, claims)
+	genuine.Header["kid"] = "rsa-1"
+	signed, err = genuine.SignedString(rsaKey)
+	require.NoError(t, err)
+	parsed, err := ks.Parse(signed)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, parsed["sub"])
+}
+
+func TestKeyWindows(t *testing.T) {
+	notBefore := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := &Key{NotBefore: notBefore, NotAfter: notAfter}
+	grace := time.Hour
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		signs    bool
+		verifies bool
+	}{
+		{name: "before NotBefore", now: notBefore.Add(-time.Second), verifies: true},
+		{name: "at NotBefore", now: notBefore, signs: true, verifies: true},
+		{name: "before NotAfter", now: notAfter.Add(-time.Second), signs: true, verifies: true},
+		{name: "at NotAfter", now: notAfter, verifies: true},
+		{name: "within the grace period", now: notAfter.Add(grace - time.Second), verifies: true},
+		{name: "at the end of the grace period", now: notAfter.Add(grace)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.signs, key.signs(tt.now))
+			assert.Equal(t, tt.verifies, key.verifies(tt.now, grace))
+		})
+	}
+
+	open := &Key{}
+	assert.True(t, open.signs(notAfter.Add(100*time.Hour)), "a key without NotAfter never stops signing")
+	assert.True(t, open.verifies(notAfter.Add(100*time.Hour), grace))
+}
+
+func TestSigningKeyPicksTheNewestRotation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	ks := rotation(t, now)
+
+	key, err := ks.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "ed-1", key.ID)
+
+	ks.now = func() time.Time { return now.Add(-time.Hour) }
+	key, err = ks.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "rsa-1", key.ID, "ed-1 has not started signing yet")
+
+	future := NewHMACKey("future", []byte("x"))
+	future.NotBefore = now.Add(time.Hour)
+	ks, err = NewKeySet(time.Hour, future)
+	require.NoError(t, err)
+	_, err = ks.SigningKey()
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = NewKeySet(time.Hour, NewHMACKey("a", []byte("x")), NewHMACKey("a", []byte("y")))
+	assert.ErrorContains(t, err, "duplicate key id")
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	rsaFile := writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ec)
+	require.NoError(t, err)
+	ecFile := writePEM(t, dir, "ec.pem", "EC PRIVATE KEY", ecDER)
+
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(ed)
+	require.NoError(t, err)
+	edFile := writePEM(t, dir, "ed.pem", "PRIVATE KEY", edDER)
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallFile := writePEM(t, dir, "small.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small))
+
+	tests := []struct {
+		name    string
+		file    string
+		algs    []string
+		wantMsg string
+	}{
+		{
+			name: "every algorithm",
+			file: `{"keys": [
+				{"kid": "rsa", "alg": "RS256", "private_key_file": "` + rsaFile + `",
+				 "not_before": "2026-10-01T00:00:00Z", "not_after": "2027-01-01T00:00:00Z"},
+				{"kid": "ec", "private_key_file": "` + ecFile + `"},
+				{"kid": "ed", "alg": "EdDSA", "private_key_file": "` + edFile + `"},
+				{"kid": "hs", "alg": "HS256", "secret": "s3cret"}
+			]}`,
+			algs: []string{AlgRS256, AlgES256, AlgEdDSA, AlgHS256},
+		},
+		{
+			name:    "missing kid",
+			file:    `{"keys": [{"alg": "HS256", "secret": "s3cret"}]}`,
+			wantMsg: "every key needs a kid",
+		},
+		{
+			name:    "HS256 without secret",
+			file:    `{"keys": [{"kid": "hs", "alg": "HS256"}]}`,
+			wantMsg: "HS256 requires a secret",
+		},
+		{
+			name:    "declared algorithm does not match the key",
+			file:    `{"keys": [{"kid": "rsa", "alg": "ES256", "private_key_file": "` + rsaFile + `"}]}`,
+			wantMsg: "RS256 key cannot sign ES256",
+		},
+		{
+			name:    "rsa key too small",
+			file:    `{"keys": [{"kid": "small", "private_key_file": "` + smallFile + `"}]}`,
+			wantMsg: "at least 2048 bits",
+		},
+		{
+			name:    "private key file is not PEM",
+			file:    `{"keys": [{"kid": "bad", "private_key_file": "` + filepath.Join(dir, "keys.json") + `"}]}`,
+			wantMsg: "is not a PEM file",
+		},
+		{
+			name:    "invalid JSON",
+			file:    `{"keys": [`,
+			wantMsg: "jwt: parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "keys.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.file), 0o600))
+
+			keys, err := LoadKeyFile(path)
+			if tt.wantMsg != "" {
+				assert.ErrorContains(t, err, tt.wantMsg)
+				return
+			}
+			require.NoError(t, err)
+			algs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				algs = append(algs, key.Algorithm)
+			}
+			assert.Equal(t, tt.algs, algs)
+			assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), keys[0].NotBefore)
+			assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), keys[0].NotAfter)
+		})
+	}
+
+	_, err = LoadKeyFile(filepath.Join(dir, "missing.json"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
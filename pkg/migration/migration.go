@@ -1,7 +1,22 @@
 package migration
 
 import (
+	accountDomain "boiler-plate/internal/account/domain"
+	authDomain "boiler-plate/internal/auth/domain"
+	clientDomain "boiler-plate/internal/client/domain"
+	customerDomain "boiler-plate/internal/customer/domain"
+	investorCategoryDomain "boiler-plate/internal/investorcategory/domain"
+	otpDomain "boiler-plate/internal/otp/domain"
 	"boiler-plate/internal/settings/domain"
+	"boiler-plate/pkg/jwt"
+	"boiler-plate/pkg/notification"
+	"boiler-plate/pkg/outbox"
+	"boiler-plate/pkg/password"
+	"boiler-plate/pkg/revocation"
+	"boiler-plate/pkg/signature"
+	"boiler-plate/pkg/storage"
+	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"os"
@@ -12,7 +27,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func Initmigrate(db *gorm.DB) {
+func Initmigrate(db *gorm.DB, store storage.Storage) {
 
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -22,10 +37,34 @@ func Initmigrate(db *gorm.DB) {
 	executePendingMigrations(db)
 
 	// Migrate rest of the models
-	logrus.Println(fmt.Println("AutoMigrate Model [table_name]"))
-	db.AutoMigrate(&domain.MainTable{})
-	logrus.Infoln(fmt.Println("  TableModel [" +
-		(&domain.MainTable{}).TableName() + "]"))
+	logrus.Infoln("AutoMigrate Model [table_name]")
+	for _, model := range []interface{ TableName() string }{
+		&domain.MainTable{},
+		&domain.SettingsRevision{},
+		&accountDomain.Role{},
+		&accountDomain.Permission{},
+		&accountDomain.RolePermission{},
+		&accountDomain.Account{},
+		&accountDomain.PasswordHistory{},
+		&authDomain.Session{},
+		&authDomain.RefreshToken{},
+		&revocation.RevokedToken{},
+		&revocation.RevokedSubject{},
+		&signature.Nonce{},
+		&password.Failure{},
+		&clientDomain.Client{},
+		&otpDomain.OTP{},
+		&notification.Delivery{},
+		&outbox.Message{},
+		&outbox.Lease{},
+		&customerDomain.Customer{},
+		&investorCategoryDomain.InvestorCategory{},
+	} {
+		if err := db.AutoMigrate(model); err != nil {
+			logrus.Fatalf("failed to migrate table [%s]: %v", model.TableName(), err)
+		}
+		logrus.Infof("  TableModel [%s]", model.TableName())
+	}
 	// Check if the Category table is empty.
 	// Check if the Category table is empty.
 	var settingsCount int64
@@ -33,8 +72,9 @@ func Initmigrate(db *gorm.DB) {
 
 	if settingsCount == 0 {
 		// The Category table is empty, so seed data.
-		seedDatabase(db)
+		seedDatabase(db, store)
 	}
+	seedAccounts(db)
 }
 
 func executePendingMigrations(db *gorm.DB) {
@@ -96,7 +136,7 @@ func checkError(err error) {
 	}
 }
 
-func seedDatabase(db *gorm.DB) {
+func seedDatabase(db *gorm.DB, store storage.Storage) {
 	// Create and insert 1-2 rows of data for the Article table.
 	timeFormat := "02-January-2006-15-4-5"
 
@@ -118,10 +158,7 @@ func seedDatabase(db *gorm.DB) {
 	if err != nil {
 		return
 	}
-	filePath := os.Getenv("FILE_PATH")
-	dst, err := os.Create(filePath + newFilename)
-	defer dst.Close()
-	if _, err = dst.Write(data); err != nil {
+	if err = store.Put(context.Background(), newFilename, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
 		return
 	}
 
@@ -129,6 +166,67 @@ func seedDatabase(db *gorm.DB) {
 
 }
 
-// func registerMigration(id string, fm mFunc) {
-// 	migrations[id] = fm
-// }
+// seedAccounts creates the roles and, when SEED_ADMIN_EMAIL and
+// SEED_ADMIN_PASSWORD are set, a verified admin account. Existing rows are
+// left untouched.
+func seedAccounts(db *gorm.DB) {
+	for _, name := range []string{jwt.UserRoleCustomer, jwt.UserRoleAdmin, jwt.UserRoleSales} {
+		role := accountDomain.Role{}
+		checkError(db.Where(accountDomain.Role{Name: name}).FirstOrCreate(&role).Error)
+	}
+
+	email, secret := accountDomain.NormalizeEmail(os.Getenv("SEED_ADMIN_EMAIL")), os.Getenv("SEED_ADMIN_PASSWORD")
+	if email == "" || secret == "" {
+		return
+	}
+	var adminCount int64
+	db.Model(&accountDomain.Account{}).Where("email = ?", email).Count(&adminCount)
+	if adminCount > 0 {
+		return
+	}
+
+	role := accountDomain.Role{}
+	checkError(db.Where("name = ?", jwt.UserRoleAdmin).First(&role).Error)
+	now := time.Now()
+	admin := &accountDomain.Account{
+		Fullname:          "Administrator",
+		Email:             email,
+		Phone:             os.Getenv("SEED_ADMIN_PHONE"),
+		Password:          password.HashPassword(secret),
+		RoleID:            role.ID,
+		EmailVerifiedAt:   &now,
+		PasswordChangedAt: &now,
+	}
+	// the first password goes to the history like a registration, so the
+	// reuse rule also covers the admin's first password change
+	checkError(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Role").Create(admin).Error; err != nil {
+			return err
+		}
+		return tx.Create(&accountDomain.PasswordHistory{
+			AccountID: admin.ID,
+			Password:  admin.Password,
+		}).Error
+	}))
+	logrus.Infoln("  Seeded admin account [" + email + "]")
+}
+
+func registerMigration(id string, fm mFunc) {
+	migrations[id] = fm
+}
+
+func init() {
+	registerMigration("20261018000000_lowercase_account_emails", lowercaseAccountEmails)
+}
+
+// lowercaseAccountEmails stores the emails of accounts created before they
+// were normalized in the form they are now looked up in.
+func lowercaseAccountEmails(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable(&accountDomain.Account{}) {
+		return nil
+	}
+	return tx.Unscoped().Model(&accountDomain.Account{}).
+		Where("email <> LOWER(email)").
+		Update("email", gorm.Expr("LOWER(email)")).
+		Error
+}
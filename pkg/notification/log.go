@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"boiler-plate/pkg/errs"
+	"context"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryTableName = "notification_deliveries"
+
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Delivery is one email or SMS handed to the notification service. Template
+// params are not kept since they may hold secrets such as OTP codes.
+type Delivery struct {
+	ID         int64     `gorm:"primaryKey;not null;autoIncrement" json:"id"`
+	Channel    string    `gorm:"size:10;not null" json:"channel"`
+	TemplateID string    `gorm:"size:100;not null" json:"template_id"`
+	Recipient  string    `gorm:"size:150;index;not null" json:"recipient"`
+	Status     string    `gorm:"size:20;index;not null" json:"status"`
+	Attempts   int       `gorm:"not null;default:0" json:"attempts"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `gorm:"type:text" json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (model *Delivery) TableName() string {
+	return os.Getenv("DB_PREFIX") + DeliveryTableName
+}
+
+// DeliveryLog persists deliveries. Record is called once before sending and
+// again with the final status.
+type DeliveryLog interface {
+	Record(ctx context.Context, delivery *Delivery) error
+}
+
+type sqlDeliveryLog struct {
+	db *gorm.DB
+}
+
+// NewSQLDeliveryLog stores deliveries in the notification_deliveries table.
+func NewSQLDeliveryLog(db *gorm.DB) DeliveryLog {
+	return &sqlDeliveryLog{db: db}
+}
+
+func (l *sqlDeliveryLog) Record(ctx context.Context, delivery *Delivery) error {
+	if err := l.db.WithContext(ctx).
+		Save(delivery).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package notification
+
+import (
+	"boiler-plate/pkg/httpclient"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+
+	// HeaderAPIKey carries Config.APIKey on every request.
+	HeaderAPIKey = "x-api-key"
+)
+
+var (
+	// ErrMissingTemplate is returned when a message has no template ID.
+	ErrMissingTemplate = errors.New("notification: template id is required")
+	// ErrMissingRecipient is returned when a message has no recipient.
+	ErrMissingRecipient = errors.New("notification: recipient is required")
+)
+
+// TemplateError reports template parameters required by a registered
+// template that were not given.
+type TemplateError struct {
+	TemplateID string
+	Missing    []string
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("notification: template %s missing params %s", e.TemplateID, strings.Join(e.Missing, ", "))
+}
+
+// DeliveryError is returned when the notification service did not accept a
+// message after all attempts.
+type DeliveryError struct {
+	StatusCode int
+	Attempts   int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("notification: delivery failed after %d attempts (status %d): %v", e.Attempts, e.StatusCode, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Message is an email or SMS rendered by the notification service from
+// TemplateID and Params.
+type Message struct {
+	To         string
+	TemplateID string
+	Params     map[string]string
+}
+
+type Email Message
+
+type SMS Message
+
+// Config points the client at the notification service.
+type Config struct {
+	EmailURL string
+	SMSURL   string
+	APIKey   string
+	// MaxRetries is the number of retries after a 5xx or transport error.
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// Client sends templated emails and SMS through the notification service and
+// records every delivery in a DeliveryLog.
+type Client struct {
+	httpClient httpclient.Client
+	config     *Config
+	log        DeliveryLog
+	templates  map[string][]string
+}
+
+// New creates a notification client. log may be nil to skip delivery logging.
+func New(httpClient httpclient.Client, config *Config, log DeliveryLog) *Client {
+	return &Client{
+		httpClient: httpClient,
+		config:     config,
+		log:        log,
+		templates:  map[string][]string{},
+	}
+}
+
+// RegisterTemplate declares the params a template needs. Messages for
+// registered templates are rejected before sending when a param is missing.
+func (c *Client) RegisterTemplate(templateID string, params ...string) {
+	c.templates[templateID] = params
+}
+
+func (c *Client) SendEmail(ctx context.Context, email Email) (*Delivery, error) {
+	return c.send(ctx, ChannelEmail, c.config.EmailURL, Message(email))
+}
+
+func (c *Client) SendSMS(ctx context.Context, sms SMS) (*Delivery, error) {
+	return c.send(ctx, ChannelSMS, c.config.SMSURL, Message(sms))
+}
+
+// Validate checks message against the registered template params.
+func (c *Client) Validate(message Message) error {
+	if message.TemplateID == "" {
+		return ErrMissingTemplate
+	}
+	if message.To == "" {
+		return ErrMissingRecipient
+	}
+	var missing []string
+	for _, param := range c.templates[message.TemplateID] {
+		if message.Params[param] == "" {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &TemplateError{TemplateID: message.TemplateID, Missing: missing}
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, channel, url string, message Message) (*Delivery, error) {
+	if err := c.Validate(message); err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		Channel:    channel,
+		TemplateID: message.TemplateID,
+		Recipient:  message.To,
+		Status:     StatusPending,
+	}
+	if err := c.record(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"template_id": message.TemplateID,
+		"recipient":   message.To,
+		"params":      message.Params,
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		HeaderAPIKey:   c.config.APIKey,
+	}
+
+	var (
+		statusCode int
+		err        error
+	)
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err = wait(ctx, c.config.RetryBackoff*time.Duration(attempt)); err != nil {
+				break
+			}
+		}
+		delivery.Attempts++
+
+		var response map[string]interface{}
+		statusCode, err = c.httpClient.PostJSON(url, payload, headers, &response)
+		// A 2xx with a body that is not JSON is still a delivery.
+		if statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices {
+			err = nil
+			break
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected status %d", statusCode)
+		}
+		if statusCode != 0 && statusCode < http.StatusInternalServerError {
+			break
+		}
+	}
+
+	delivery.StatusCode = statusCode
+	if err != nil {
+		delivery.Status = StatusFailed
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = StatusSent
+	}
+	if logErr := c.record(ctx, delivery); logErr != nil && err == nil {
+		return delivery, logErr
+	}
+	if err != nil {
+		return delivery, &DeliveryError{StatusCode: statusCode, Attempts: delivery.Attempts, Err: err}
+	}
+	return delivery, nil
+}
+
+func (c *Client) record(ctx context.Context, delivery *Delivery) error {
+	if c.log == nil {
+		return nil
+	}
+	return c.log.Record(ctx, delivery)
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
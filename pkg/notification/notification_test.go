@@ -0,0 +1,199 @@
+package notification_test
+
+import (
+	"boiler-plate/pkg/httpclient"
+	"boiler-plate/pkg/notification"
+	"boiler-plate/pkg/notification/notificationtest"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apiKey = "test-api-key"
+
+// memoryLog keeps a copy of every recorded delivery state.
+type memoryLog struct {
+	mu      sync.Mutex
+	records []notification.Delivery
+}
+
+func (l *memoryLog) Record(ctx context.Context, delivery *notification.Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, *delivery)
+	return nil
+}
+
+func newClient(t *testing.T, maxRetries int) (*notification.Client, *notificationtest.Server, *memoryLog) {
+	server := notificationtest.NewServer(apiKey)
+	t.Cleanup(server.Close)
+
+	config := server.Config()
+	config.MaxRetries = maxRetries
+	config.RetryBackoff = time.Millisecond
+	log := &memoryLog{}
+	client := notification.New(httpclient.New().CreateClient(), config, log)
+	client.RegisterTemplate("otp-email", "otp")
+	return client, server, log
+}
+
+func TestSendEmail(t *testing.T) {
+	client, server, log := newClient(t, 0)
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "otp-email",
+		Params:     map[string]string{"otp": "123456"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, notification.StatusSent, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.StatusCode)
+
+	received, ok := server.Last("user@example.com")
+	require.True(t, ok)
+	assert.Equal(t, notification.ChannelEmail, received.Channel)
+	assert.Equal(t, "otp-email", received.TemplateID)
+	assert.Equal(t, map[string]string{"otp": "123456"}, received.Params)
+
+	require.Len(t, log.records, 2)
+	assert.Equal(t, notification.StatusPending, log.records[0].Status)
+	assert.Equal(t, notification.StatusSent, log.records[1].Status)
+}
+
+func TestSendSMS(t *testing.T) {
+	client, server, _ := newClient(t, 0)
+
+	_, err := client.SendSMS(context.Background(), notification.SMS{
+		To:         "+6281234567890",
+		TemplateID: "otp-sms",
+		Params:     map[string]string{"otp": "654321"},
+	})
+	require.NoError(t, err)
+
+	received, ok := server.Last("+6281234567890")
+	require.True(t, ok)
+	assert.Equal(t, notification.ChannelSMS, received.Channel)
+}
+
+func TestSendValidatesTemplateParams(t *testing.T) {
+	client, server, log := newClient(t, 0)
+	ctx := context.Background()
+
+	_, err := client.SendEmail(ctx, notification.Email{To: "user@example.com", TemplateID: "otp-email"})
+	var templateErr *notification.TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	assert.Equal(t, []string{"otp"}, templateErr.Missing)
+
+	_, err = client.SendEmail(ctx, notification.Email{To: "user@example.com"})
+	assert.ErrorIs(t, err, notification.ErrMissingTemplate)
+	_, err = client.SendEmail(ctx, notification.Email{TemplateID: "welcome"})
+	assert.ErrorIs(t, err, notification.ErrMissingRecipient)
+
+	assert.Empty(t, server.Received())
+	assert.Empty(t, log.records)
+}
+
+func TestSendRetriesServerErrors(t *testing.T) {
+	client, server, log := newClient(t, 2)
+	server.FailNext(http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "welcome",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, notification.StatusSent, delivery.Status)
+	assert.Len(t, server.Received(), 1)
+	assert.Equal(t, notification.StatusSent, log.records[len(log.records)-1].Status)
+}
+
+func TestSendGivesUpAfterMaxRetries(t *testing.T) {
+	client, server, log := newClient(t, 2)
+	server.FailNext(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "welcome",
+	})
+	var deliveryErr *notification.DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, 3, deliveryErr.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, deliveryErr.StatusCode)
+	assert.Equal(t, notification.StatusFailed, delivery.Status)
+	assert.NotEmpty(t, delivery.Error)
+	assert.Empty(t, server.Received())
+
+	last := log.records[len(log.records)-1]
+	assert.Equal(t, notification.StatusFailed, last.Status)
+	assert.Equal(t, 3, last.Attempts)
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	client, server, _ := newClient(t, 3)
+	server.FailNext(http.StatusBadRequest)
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "welcome",
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusBadRequest, delivery.StatusCode)
+}
+
+func TestSendRejectedAPIKey(t *testing.T) {
+	server := notificationtest.NewServer(apiKey)
+	defer server.Close()
+	config := server.Config()
+	config.APIKey = "wrong"
+	config.MaxRetries = 2
+	client := notification.New(httpclient.New().CreateClient(), config, nil)
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "welcome",
+	})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, delivery.StatusCode)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Empty(t, server.Received())
+}
+
+func TestSendRetriesTransportErrors(t *testing.T) {
+	client, server, _ := newClient(t, 1)
+	server.Close()
+
+	delivery, err := client.SendEmail(context.Background(), notification.Email{
+		To:         "user@example.com",
+		TemplateID: "welcome",
+	})
+	var deliveryErr *notification.DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, 0, deliveryErr.StatusCode)
+	assert.Equal(t, 2, delivery.Attempts)
+}
+
+func TestSendStopsWhenContextIsDone(t *testing.T) {
+	server := notificationtest.NewServer(apiKey)
+	defer server.Close()
+	config := server.Config()
+	config.MaxRetries = 5
+	config.RetryBackoff = time.Hour
+	client := notification.New(httpclient.New().CreateClient(), config, nil)
+	server.FailNext(http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	delivery, err := client.SendEmail(ctx, notification.Email{To: "user@example.com", TemplateID: "welcome"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 1, delivery.Attempts)
+}
@@ -0,0 +1,122 @@
+// Package notificationtest provides an in-process notification service for
+// tests, in the spirit of net/http/httptest.
+package notificationtest
+
+import (
+	"boiler-plate/pkg/notification"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+const (
+	EmailPath = "/email"
+	SMSPath   = "/sms"
+)
+
+// Received is a message accepted by the fake server.
+type Received struct {
+	Channel    string            `json:"channel"`
+	TemplateID string            `json:"template_id"`
+	Recipient  string            `json:"recipient"`
+	Params     map[string]string `json:"params"`
+}
+
+// Server is a fake notification service. It checks the API key, records
+// accepted messages and can be told to fail the next requests.
+type Server struct {
+	*httptest.Server
+	APIKey string
+
+	mu       sync.Mutex
+	received []Received
+	failures []int
+}
+
+// NewServer starts a fake notification service expecting apiKey.
+func NewServer(apiKey string) *Server {
+	s := &Server{APIKey: apiKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc(EmailPath, s.handle(notification.ChannelEmail))
+	mux.HandleFunc(SMSPath, s.handle(notification.ChannelSMS))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config returns a client config pointing at the server.
+func (s *Server) Config() *notification.Config {
+	return &notification.Config{
+		EmailURL: s.URL + EmailPath,
+		SMSURL:   s.URL + SMSPath,
+		APIKey:   s.APIKey,
+	}
+}
+
+// FailNext makes the next len(statuses) requests answer with the given
+// status codes, in order.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Received returns the accepted messages in arrival order.
+func (s *Server) Received() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Received(nil), s.received...)
+}
+
+// Last returns the last accepted message sent to recipient.
+func (s *Server) Last(recipient string) (Received, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.received) - 1; i >= 0; i-- {
+		if s.received[i].Recipient == recipient {
+			return s.received[i], true
+		}
+	}
+	return Received{}, false
+}
+
+func (s *Server) handle(channel string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if r.Header.Get(notification.HeaderAPIKey) != s.APIKey {
+			writeJSON(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+
+		s.mu.Lock()
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			s.mu.Unlock()
+			writeJSON(w, status, "failure requested")
+			return
+		}
+		s.mu.Unlock()
+
+		message := Received{Channel: channel}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			writeJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		message.Channel = channel
+
+		s.mu.Lock()
+		s.received = append(s.received, message)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, "success")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status_code": status, "message": message})
+}
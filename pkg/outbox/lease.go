@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"time"
+
+	baseModel "boiler-plate/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+const LeaseTableName = "outbox_leases"
+
+// Lease elects the relay allowed to publish. Every process runs a relay, the
+// one holding the lease publishes and the others wait for it to expire.
+type Lease struct {
+	Name      string    `gorm:"primaryKey;size:100" json:"name"`
+	Owner     string    `gorm:"size:100;not null" json:"owner"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
+
+func (model *Lease) TableName() string {
+	return os.Getenv("DB_PREFIX") + LeaseTableName
+}
+
+// acquireLease takes or renews the lease name for owner until ttl from now.
+// It reports false while another owner holds an unexpired lease.
+func acquireLease(ctx context.Context, db *gorm.DB, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	result := db.WithContext(ctx).
+		Model(&Lease{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]interface{}{
+			"owner":      owner,
+			"expires_at": now.Add(ttl),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// the first relay creates the row, the primary key settles a race
+	err := db.WithContext(ctx).Create(&Lease{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)}).Error
+	if baseModel.IsDuplicateKey(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// releaseLease lets another relay take over without waiting for the expiry.
+func releaseLease(ctx context.Context, db *gorm.DB, name, owner string) error {
+	return db.WithContext(ctx).
+		Model(&Lease{}).
+		Where("name = ? AND owner = ?", name, owner).
+		Update("expires_at", time.Now()).
+		Error
+}
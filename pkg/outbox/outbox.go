@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"os"
+	"time"
+
+	"boiler-plate/pkg/event"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TableName = "outbox_messages"
+
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Message is an event waiting to be published to Kafka. Rows are written in
+// the same transaction as the change they describe and published by the Relay.
+type Message struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Topic         string     `gorm:"size:255;not null" json:"topic"`
+	Key           string     `gorm:"size:255;index" json:"key"`
+	Payload       string     `gorm:"type:text" json:"payload"`
+	Status        string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (model *Message) TableName() string {
+	return os.Getenv("DB_PREFIX") + TableName
+}
+
+// Event is stored by Outbox.Enqueue. An empty Topic means the event is not
+// configured and nothing is stored.
+type Event struct {
+	Topic   string
+	Key     string
+	Type    string
+	Version int
+	Data    interface{}
+}
+
+// Outbox wraps events in an envelope validated against the schema registry
+// and stores them for the Relay.
+type Outbox struct {
+	registry *event.Registry
+	producer string
+}
+
+func New(registry *event.Registry, producer string) *Outbox {
+	return &Outbox{registry: registry, producer: producer}
+}
+
+// Enqueue stores e as a pending message. tx must be the transaction of the
+// change so the event exists exactly when it commits, an invalid payload
+// aborts it.
+func (o *Outbox) Enqueue(tx *gorm.DB, requestID string, e *Event) error {
+	if e.Topic == "" {
+		return nil
+	}
+	envelope, err := event.NewEnvelope(e.Type, e.Version, e.Data, o.producer, requestID)
+	if err != nil {
+		return err
+	}
+	value, err := o.registry.Encode(envelope)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&Message{
+		Topic:   e.Topic,
+		Key:     e.Key,
+		Payload: string(value),
+		Status:  StatusPending,
+	}).Error
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"boiler-plate/pkg/errs"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Purger deletes delivered messages once they are older than the retention.
+// Failed messages are kept until someone looks at them.
+type Purger struct {
+	db            *gorm.DB
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+// NewPurger creates a Purger, Run deletes delivered messages every
+// purgeInterval.
+func NewPurger(db *gorm.DB, retention, purgeInterval time.Duration) *Purger {
+	return &Purger{db: db, retention: retention, purgeInterval: purgeInterval}
+}
+
+// Purge deletes the messages delivered before the retention.
+func (p *Purger) Purge(ctx context.Context) error {
+	if err := p.db.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", StatusDelivered, time.Now().Add(-p.retention)).
+		Delete(&Message{}).
+		Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Run purges delivered messages until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) error {
+	for {
+		if err := p.Purge(ctx); err != nil {
+			logrus.Errorf("outbox purge error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.purgeInterval):
+		}
+	}
+}
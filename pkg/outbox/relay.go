@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxRetryBackoff = 10 * time.Minute
+	relayLeaseName  = "outbox-relay"
+)
+
+// Publisher sends messages to a topic and returns once they are acknowledged.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, messages ...kafka.Message) error
+}
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts after which a message is marked failed and no longer
+	// blocks the messages behind it, zero retries forever.
+	MaxAttempts int
+	// RetryBackoff doubles with every failed attempt up to ten minutes.
+	RetryBackoff time.Duration
+	// LeaseTTL is how long the elected relay keeps publishing without
+	// renewing its lease, it is renewed on every poll.
+	LeaseTTL time.Duration
+}
+
+// Relay publishes pending outbox messages in insert order. Messages sharing a
+// key are published one after another: when one fails, the rest of that key
+// waits for its retry while other keys go on. Any number of relays may run,
+// only the one holding the lease publishes so the per key order holds across
+// processes. Delivery is at least once, keep consumers idempotent.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	config    *RelayConfig
+	owner     string
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, config *RelayConfig) *Relay {
+	hostname, _ := os.Hostname()
+	return &Relay{db: db, publisher: publisher, config: config, owner: hostname + "-" + uuid.NewString()}
+}
+
+// Run polls the outbox until ctx is cancelled. A full batch is followed by
+// the next one straight away.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		published, err := r.RelayOnce(ctx)
+		if err != nil {
+			logrus.Errorf("outbox relay error: %v", err)
+		}
+
+		wait := r.config.PollInterval
+		if err == nil && published >= r.config.BatchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			if err := releaseLease(context.WithoutCancel(ctx), r.db, relayLeaseName, r.owner); err != nil {
+				logrus.Errorf("failed to release outbox relay lease: %v", err)
+			}
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending messages and returns how many
+// were delivered. It does nothing while another relay holds the lease.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	leader, err := acquireLease(ctx, r.db, relayLeaseName, r.owner, r.config.LeaseTTL)
+	if err != nil || !leader {
+		return 0, err
+	}
+	// stop well before the lease expires so a new leader never overlaps
+	deadline := time.Now().Add(r.config.LeaseTTL / 2)
+
+	// Keys whose oldest pending message waits for a retry are skipped in
+	// SQL, so they never fill the batch and stall the other keys.
+	now := time.Now()
+	table := (&Message{}).TableName()
+	var messages []Message
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", StatusPending).
+		Where("NOT EXISTS (SELECT 1 FROM ? blocked WHERE blocked.status = ? AND blocked.next_attempt_at > ? AND ? = ?)",
+			clause.Table{Name: table}, StatusPending, now,
+			clause.Column{Table: "blocked", Name: "key"}, clause.Column{Table: table, Name: "key"}).
+		Order("id asc").
+		Limit(r.config.BatchSize).
+		Find(&messages).
+		Error; err != nil {
+		return 0, err
+	}
+
+	// The in flight message is finished even when ctx is cancelled so its
+	// status matches what was published, the rest waits for the next run.
+	stop := ctx.Done()
+	ctx = context.WithoutCancel(ctx)
+	blocked := map[string]bool{}
+	published := 0
+	for i := range messages {
+		select {
+		case <-stop:
+			return published, nil
+		default:
+		}
+		if time.Now().After(deadline) {
+			return published, nil
+		}
+
+		message := &messages[i]
+		if blocked[message.Key] {
+			continue
+		}
+		if message.NextAttemptAt != nil && message.NextAttemptAt.After(now) {
+			blocked[message.Key] = true
+			continue
+		}
+
+		err := r.publisher.Publish(ctx, message.Topic, kafka.Message{
+			Key:   []byte(message.Key),
+			Value: []byte(message.Payload),
+		})
+		if err != nil {
+			if r.markRetry(ctx, message, err) {
+				blocked[message.Key] = true
+			}
+			continue
+		}
+		if err := r.markDelivered(ctx, message); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (r *Relay) markDelivered(ctx context.Context, message *Message) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&Message{}).
+		Where("id = ? AND status = ?", message.ID, StatusPending).
+		Updates(map[string]interface{}{
+			"status":       StatusDelivered,
+			"attempts":     message.Attempts + 1,
+			"last_error":   "",
+			"delivered_at": &now,
+		}).Error
+}
+
+// markRetry records the failure and reports whether the message will be
+// retried, in which case later messages with the same key must wait.
+func (r *Relay) markRetry(ctx context.Context, message *Message, publishErr error) bool {
+	attempts := message.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": publishErr.Error(),
+	}
+
+	retry := r.config.MaxAttempts == 0 || attempts < r.config.MaxAttempts
+	if retry {
+		backoff := r.config.RetryBackoff
+		for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+			backoff *= 2
+		}
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+		next := time.Now().Add(backoff)
+		updates["next_attempt_at"] = &next
+		logrus.Warnf("outbox message %d to %s failed, attempt %d: %v", message.ID, message.Topic, attempts, publishErr)
+	} else {
+		updates["status"] = StatusFailed
+		logrus.Errorf("outbox message %d to %s failed after %d attempts: %v",
+			message.ID, message.Topic, attempts, publishErr)
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(&Message{}).
+		Where("id = ? AND status = ?", message.ID, StatusPending).
+		Updates(updates).
+		Error; err != nil {
+		logrus.Errorf("failed to update outbox message %d: %v", message.ID, err)
+	}
+	return retry
+}
@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakePublisher records published payloads and fails the payloads listed in
+// fail once per entry.
+type fakePublisher struct {
+	mu        sync.Mutex
+	fail      map[string]int
+	published []string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, _ string, messages ...kafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, message := range messages {
+		payload := string(message.Value)
+		if p.fail[payload] > 0 {
+			p.fail[payload]--
+			return errors.New("broker unavailable")
+		}
+		p.published = append(p.published, payload)
+	}
+	return nil
+}
+
+func (p *fakePublisher) take() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	published := p.published
+	p.published = nil
+	return published
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Message{}, &Lease{}))
+	return db
+}
+
+func enqueue(t *testing.T, db *gorm.DB, key string, payloads ...string) {
+	for _, payload := range payloads {
+		require.NoError(t, db.Create(&Message{Topic: "events", Key: key, Payload: payload, Status: StatusPending}).Error)
+	}
+}
+
+func testConfig() *RelayConfig {
+	return &RelayConfig{
+		PollInterval: time.Millisecond,
+		BatchSize:    100,
+		MaxAttempts:  3,
+		RetryBackoff: time.Hour,
+		LeaseTTL:     time.Minute,
+	}
+}
+
+// retryNow makes every message waiting for a retry due.
+func retryNow(t *testing.T, db *gorm.DB) {
+	require.NoError(t, db.Model(&Message{}).
+		Where("next_attempt_at IS NOT NULL").
+		Update("next_attempt_at", time.Now().Add(-time.Second)).
+		Error)
+}
+
+func statuses(t *testing.T, db *gorm.DB) map[string]string {
+	var messages []Message
+	require.NoError(t, db.Order("id").Find(&messages).Error)
+	result := map[string]string{}
+	for _, message := range messages {
+		result[message.Payload] = message.Status
+	}
+	return result
+}
+
+func TestRelayKeepsPerKeyOrder(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	publisher := &fakePublisher{fail: map[string]int{"a1": 1}}
+	relay := NewRelay(db, publisher, testConfig())
+
+	enqueue(t, db, "a", "a1")
+	enqueue(t, db, "b", "b1")
+	enqueue(t, db, "a", "a2")
+	enqueue(t, db, "b", "b2")
+
+	published, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"b1", "b2"}, publisher.take(), "a2 waits behind the failed a1, b goes on")
+
+	published, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "a is skipped until its retry is due")
+
+	retryNow(t, db)
+	published, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"a1", "a2"}, publisher.take())
+
+	var a1 Message
+	require.NoError(t, db.Where("payload = ?", "a1").First(&a1).Error)
+	assert.Equal(t, StatusDelivered, a1.Status)
+	assert.Equal(t, 2, a1.Attempts)
+	assert.Empty(t, a1.LastError)
+}
+
+func TestRelaySkipsBlockedKeysInSQL(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	publisher := &fakePublisher{fail: map[string]int{"a1": 1}}
+	config := testConfig()
+	config.BatchSize = 1
+	relay := NewRelay(db, publisher, config)
+
+	enqueue(t, db, "a", "a1", "a2", "a3")
+	enqueue(t, db, "b", "b1")
+
+	published, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "a1 failed")
+
+	// a batch of one would be a1 forever if blocked keys were filtered after
+	// the query
+	published, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"b1"}, publisher.take())
+}
+
+func TestRelayMarksFailedAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	publisher := &fakePublisher{fail: map[string]int{"a1": 3}}
+	relay := NewRelay(db, publisher, testConfig())
+
+	enqueue(t, db, "a", "a1", "a2")
+	for i := 0; i < 3; i++ {
+		_, err := relay.RelayOnce(ctx)
+		require.NoError(t, err)
+		retryNow(t, db)
+	}
+	assert.Equal(t, []string{"a2"}, publisher.take(), "a failed message no longer blocks its key")
+	assert.Equal(t, map[string]string{"a1": StatusFailed, "a2": StatusDelivered}, statuses(t, db))
+}
+
+func TestRelayLeaseHandover(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	config := testConfig()
+	config.LeaseTTL = 200 * time.Millisecond
+	first, second := &fakePublisher{}, &fakePublisher{}
+	leader := NewRelay(db, first, config)
+	standby := NewRelay(db, second, config)
+
+	enqueue(t, db, "a", "a1")
+	published, err := leader.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	enqueue(t, db, "a", "a2")
+	published, err = standby.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "the lease is held by the leader")
+	assert.Empty(t, second.take())
+
+	// the leader stopped without releasing, the lease expires
+	time.Sleep(config.LeaseTTL + 50*time.Millisecond)
+	published, err = standby.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"a2"}, second.take())
+
+	published, err = leader.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "the old leader waits for the new one")
+
+	// Run releases the lease on shutdown, the other relay takes over at once
+	enqueue(t, db, "a", "a3")
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- standby.Run(runCtx) }()
+	require.Eventually(t, func() bool {
+		return statuses(t, db)["a3"] == StatusDelivered
+	}, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	enqueue(t, db, "a", "a4")
+	published, err = leader.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"a1", "a4"}, first.take())
+}
+
+func TestPurgerDeletesDeliveredMessages(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	old, recent := time.Now().Add(-48*time.Hour), time.Now()
+	require.NoError(t, db.Create([]*Message{
+		{Topic: "events", Key: "a", Payload: "old", Status: StatusDelivered, DeliveredAt: &old},
+		{Topic: "events", Key: "a", Payload: "recent", Status: StatusDelivered, DeliveredAt: &recent},
+		{Topic: "events", Key: "b", Payload: "failed", Status: StatusFailed},
+		{Topic: "events", Key: "c", Payload: "pending", Status: StatusPending},
+	}).Error)
+
+	require.NoError(t, NewPurger(db, 24*time.Hour, time.Hour).Purge(ctx))
+	assert.Equal(t, map[string]string{
+		"recent":  StatusDelivered,
+		"failed":  StatusFailed,
+		"pending": StatusPending,
+	}, statuses(t, db))
+}
@@ -0,0 +1,174 @@
+package password
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"boiler-plate/pkg/memstorage"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// LockoutStore counts failed password attempts per key (user id, email...).
+// The counter of a key expires window after its last failure.
+type LockoutStore interface {
+	// Increment adds a failed attempt to key and returns the attempts made
+	// since the counter last expired.
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
+	// Attempts returns the failed attempts of key that have not expired.
+	Attempts(ctx context.Context, key string) (int, error)
+	// Reset clears the failed attempts of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// MemoryLockoutStore keeps the counters in process memory. Every pod counts
+// on its own and a restart forgets them, use SQLLockoutStore when the service
+// runs outside a single local process.
+type MemoryLockoutStore struct {
+	attempts *memstorage.MemStorage[string, int]
+	mu       sync.Mutex // Serializes read-increment-write on the same key.
+}
+
+func NewMemoryLockoutStore() *MemoryLockoutStore {
+	return &MemoryLockoutStore{attempts: memstorage.NewMemStorage[string, int]()}
+}
+
+func (s *MemoryLockoutStore) Increment(_ context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, found := s.attempts.Get(key)
+	if !found {
+		attempts = 0
+	}
+	attempts++
+	s.attempts.Set(key, attempts, window)
+	return attempts, nil
+}
+
+func (s *MemoryLockoutStore) Attempts(_ context.Context, key string) (int, error) {
+	// an expired item still returns its value
+	if attempts, found := s.attempts.Get(key); found {
+		return attempts, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryLockoutStore) Reset(_ context.Context, key string) error {
+	s.attempts.Remove(key)
+	return nil
+}
+
+// Failure counts the failed attempts of a hashed key until ExpiredAt.
+type Failure struct {
+	Digest    string    `gorm:"primaryKey;size:64" json:"digest"`
+	Attempts  int       `gorm:"not null;default:0" json:"attempts"`
+	ExpiredAt time.Time `gorm:"index;not null" json:"expired_at"`
+}
+
+func (model *Failure) TableName() string {
+	return os.Getenv("DB_PREFIX") + "password_failures"
+}
+
+// SQLLockoutStore shares the counters between every pod through the
+// database, so the limit holds whichever pod a login reaches and survives a
+// restart.
+type SQLLockoutStore struct {
+	db            *gorm.DB
+	purgeInterval time.Duration
+}
+
+// NewSQLLockoutStore creates a SQLLockoutStore, Run deletes expired rows
+// every purgeInterval.
+func NewSQLLockoutStore(db *gorm.DB, purgeInterval time.Duration) *SQLLockoutStore {
+	return &SQLLockoutStore{db: db, purgeInterval: purgeInterval}
+}
+
+// digest hashes key to fit the column whatever its length.
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *SQLLockoutStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	hashed := digest(key)
+	for retry := 0; ; retry++ {
+		now := time.Now()
+		// the count and the expiry are one statement so parallel failures
+		// are all counted; an expired counter starts over at one
+		result := s.db.WithContext(ctx).
+			Model(&Failure{}).
+			Where("digest = ?", hashed).
+			Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("CASE WHEN expired_at > ? THEN attempts + 1 ELSE 1 END", now),
+				"expired_at": now.Add(window),
+			})
+		if result.Error != nil {
+			return 0, errs.Wrap(result.Error)
+		}
+		if result.RowsAffected > 0 {
+			break
+		}
+
+		err := s.db.WithContext(ctx).Create(&Failure{Digest: hashed, Attempts: 1, ExpiredAt: now.Add(window)}).Error
+		if err == nil {
+			return 1, nil
+		}
+		// another pod created the row first, count on it
+		if !baseModel.IsDuplicateKey(err) || retry > 0 {
+			return 0, errs.Wrap(err)
+		}
+	}
+	return s.Attempts(ctx, key)
+}
+
+func (s *SQLLockoutStore) Attempts(ctx context.Context, key string) (int, error) {
+	var model Failure
+	if err := s.db.WithContext(ctx).
+		Where("digest = ? AND expired_at > ?", digest(key), time.Now()).
+		First(&model).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, errs.Wrap(err)
+	}
+	return model.Attempts, nil
+}
+
+func (s *SQLLockoutStore) Reset(ctx context.Context, key string) error {
+	if err := s.db.WithContext(ctx).Where("digest = ?", digest(key)).Delete(&Failure{}).Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Purge deletes expired counters.
+func (s *SQLLockoutStore) Purge(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).Where("expired_at <= ?", time.Now()).Delete(&Failure{}).Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Run purges expired counters until ctx is cancelled.
+func (s *SQLLockoutStore) Run(ctx context.Context) error {
+	for {
+		if err := s.Purge(ctx); err != nil {
+			logrus.Errorf("password lockout purge error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.purgeInterval):
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type staticPolicy struct {
+	policy *Policy
+	err    error
+}
+
+func (s staticPolicy) PasswordPolicy(context.Context) (*Policy, error) {
+	return s.policy, s.err
+}
+
+func newSQLLockoutStore(t *testing.T) *SQLLockoutStore {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lockout.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Failure{}))
+	return NewSQLLockoutStore(db, time.Minute)
+}
+
+func lockoutStores(t *testing.T) map[string]LockoutStore {
+	return map[string]LockoutStore{
+		"memory": NewMemoryLockoutStore(),
+		"sql":    newSQLLockoutStore(t),
+	}
+}
+
+func TestEngineLockout(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			engine := NewEngine(staticPolicy{policy: &Policy{MaxInvalid: 3}}, store, time.Hour)
+
+			for want := 1; want < 3; want++ {
+				attempts, locked, err := engine.RegisterFailure(ctx, "a@example.com")
+				require.NoError(t, err)
+				assert.Equal(t, want, attempts)
+				assert.False(t, locked, "below the threshold")
+			}
+			locked, err := engine.IsLocked(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.False(t, locked)
+
+			attempts, locked, err := engine.RegisterFailure(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, 3, attempts)
+			assert.True(t, locked, "at the threshold")
+			locked, err = engine.IsLocked(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.True(t, locked)
+
+			locked, err = engine.IsLocked(ctx, "b@example.com")
+			require.NoError(t, err)
+			assert.False(t, locked, "other keys are not locked")
+
+			require.NoError(t, engine.ResetFailures(ctx, "a@example.com"))
+			locked, err = engine.IsLocked(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.False(t, locked, "unlocked after a reset")
+		})
+	}
+}
+
+func TestEngineLockoutDisabled(t *testing.T) {
+	ctx := context.Background()
+	engine := NewEngine(staticPolicy{policy: &Policy{}}, NewMemoryLockoutStore(), time.Hour)
+	for i := 0; i < 10; i++ {
+		_, locked, err := engine.RegisterFailure(ctx, "a@example.com")
+		require.NoError(t, err)
+		assert.False(t, locked)
+	}
+	locked, err := engine.IsLocked(ctx, "a@example.com")
+	require.NoError(t, err)
+	assert.False(t, locked, "a MaxInvalid of zero never locks")
+
+	policyErr := errors.New("settings unavailable")
+	engine = NewEngine(staticPolicy{err: policyErr}, NewMemoryLockoutStore(), time.Hour)
+	_, err = engine.IsLocked(ctx, "a@example.com")
+	assert.ErrorIs(t, err, policyErr)
+}
+
+func TestLockoutStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range lockoutStores(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Increment(ctx, "a@example.com", 50*time.Millisecond)
+			require.NoError(t, err)
+			attempts, err := store.Increment(ctx, "a@example.com", 50*time.Millisecond)
+			require.NoError(t, err)
+			require.Equal(t, 2, attempts)
+
+			time.Sleep(100 * time.Millisecond)
+			attempts, err = store.Attempts(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.Zero(t, attempts, "the counter expired")
+			attempts, err = store.Increment(ctx, "a@example.com", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, 1, attempts, "an expired counter starts over")
+		})
+	}
+}
+
+func TestSQLLockoutStoreSharedBetweenPods(t *testing.T) {
+	ctx := context.Background()
+	first := newSQLLockoutStore(t)
+	second := NewSQLLockoutStore(first.db, time.Minute)
+
+	_, err := first.Increment(ctx, "a@example.com", time.Minute)
+	require.NoError(t, err)
+	attempts, err := second.Increment(ctx, "a@example.com", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts, "every pod counts on the same row")
+
+	_, err = first.Increment(ctx, "expired@example.com", -time.Second)
+	require.NoError(t, err)
+	require.NoError(t, first.Purge(ctx))
+	var count int64
+	require.NoError(t, first.db.Model(&Failure{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "only the expired counter is purged")
+}
@@ -0,0 +1,245 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Rule identifies a single password policy check.
+type Rule string
+
+const (
+	RuleLength    Rule = "length"
+	RuleNumeric   Rule = "numeric"
+	RuleAlphabet  Rule = "alphabet"
+	RuleUppercase Rule = "uppercase"
+	RuleSymbol    Rule = "symbol"
+	RuleReuse     Rule = "reuse"
+)
+
+const (
+	PeriodDays   = "days"
+	PeriodMonths = "months"
+	PeriodYears  = "years"
+)
+
+// Policy holds the password rules configured in the settings table.
+type Policy struct {
+	MinLength        int
+	RequireNumeric   bool
+	RequireAlphabet  bool
+	RequireUppercase bool
+	RequireSymbol    bool
+	// History is how many previous passwords may not be reused.
+	History int
+	// ExpirationCount and ExpiredPeriod define how long a password is valid,
+	// e.g. 3 months. A zero count means the password never expires.
+	ExpirationCount int
+	ExpiredPeriod   string
+	// MaxInvalid is the number of failed attempts before an account is locked.
+	// Zero disables the lockout.
+	MaxInvalid int
+}
+
+// PolicySource loads the current policy, typically from the settings service.
+type PolicySource interface {
+	PasswordPolicy(ctx context.Context) (*Policy, error)
+}
+
+// Violation describes a rule the candidate password does not satisfy.
+type Violation struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError is returned when a candidate password breaks one or more rules.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Validate checks the candidate against the complexity rules and returns every
+// violation found, or nil when the password is acceptable.
+func (p Policy) Validate(candidate string) []Violation {
+	var hasNumeric, hasAlphabet, hasUppercase, hasSymbol bool
+	for _, r := range candidate {
+		switch {
+		case unicode.IsDigit(r):
+			hasNumeric = true
+		case unicode.IsLetter(r):
+			hasAlphabet = true
+			if unicode.IsUpper(r) {
+				hasUppercase = true
+			}
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var violations []Violation
+	if p.MinLength > 0 && len([]rune(candidate)) < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleLength,
+			Message: fmt.Sprintf("password must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.RequireNumeric && !hasNumeric {
+		violations = append(violations, Violation{
+			Rule:    RuleNumeric,
+			Message: "password must contain a number",
+		})
+	}
+	if p.RequireAlphabet && !hasAlphabet {
+		violations = append(violations, Violation{
+			Rule:    RuleAlphabet,
+			Message: "password must contain a letter",
+		})
+	}
+	if p.RequireUppercase && !hasUppercase {
+		violations = append(violations, Violation{
+			Rule:    RuleUppercase,
+			Message: "password must contain an uppercase letter",
+		})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{
+			Rule:    RuleSymbol,
+			Message: "password must contain a symbol",
+		})
+	}
+	return violations
+}
+
+// IsReused reports whether candidate matches one of the last History hashes.
+// history must be ordered from the most recent hash.
+func (p Policy) IsReused(candidate string, history []string) bool {
+	if p.History <= 0 {
+		return false
+	}
+	if len(history) > p.History {
+		history = history[:p.History]
+	}
+	for _, hash := range history {
+		if CheckPassword(hash, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiresAt returns when a password changed at from expires, or nil if the
+// policy has no expiry.
+func (p Policy) ExpiresAt(from time.Time) *time.Time {
+	if p.ExpirationCount <= 0 {
+		return nil
+	}
+
+	var expiry time.Time
+	switch p.ExpiredPeriod {
+	case PeriodMonths:
+		expiry = from.AddDate(0, p.ExpirationCount, 0)
+	case PeriodYears:
+		expiry = from.AddDate(p.ExpirationCount, 0, 0)
+	default:
+		expiry = from.AddDate(0, 0, p.ExpirationCount)
+	}
+	return &expiry
+}
+
+// Engine enforces the current policy loaded from a PolicySource.
+type Engine struct {
+	source        PolicySource
+	lockout       LockoutStore
+	lockoutWindow time.Duration
+}
+
+// NewEngine creates an Engine counting failed attempts in lockout. Failed
+// attempts are forgotten after lockoutWindow without a new failure.
+func NewEngine(source PolicySource, lockout LockoutStore, lockoutWindow time.Duration) *Engine {
+	return &Engine{
+		source:        source,
+		lockout:       lockout,
+		lockoutWindow: lockoutWindow,
+	}
+}
+
+// Policy returns the current policy.
+func (e *Engine) Policy(ctx context.Context) (*Policy, error) {
+	return e.source.PasswordPolicy(ctx)
+}
+
+// Validate checks candidate against the current policy and the previous
+// password hashes. It returns a *PolicyError listing every violation.
+func (e *Engine) Validate(ctx context.Context, candidate string, history []string) error {
+	policy, err := e.source.PasswordPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	violations := policy.Validate(candidate)
+	if policy.IsReused(candidate, history) {
+		violations = append(violations, Violation{
+			Rule:    RuleReuse,
+			Message: fmt.Sprintf("password must not match the last %d passwords", policy.History),
+		})
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// ExpiresAt returns when a password changed at from expires under the current
+// policy, or nil if passwords never expire.
+func (e *Engine) ExpiresAt(ctx context.Context, from time.Time) (*time.Time, error) {
+	policy, err := e.source.PasswordPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return policy.ExpiresAt(from), nil
+}
+
+// RegisterFailure records a failed attempt for key and reports whether the
+// key is now locked under the current policy. A MaxInvalid of zero never locks.
+func (e *Engine) RegisterFailure(ctx context.Context, key string) (int, bool, error) {
+	policy, err := e.source.PasswordPolicy(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	attempts, err := e.lockout.Increment(ctx, key, e.lockoutWindow)
+	if err != nil {
+		return 0, false, err
+	}
+	return attempts, policy.MaxInvalid > 0 && attempts >= policy.MaxInvalid, nil
+}
+
+// IsLocked reports whether key reached the maximum failed attempts.
+func (e *Engine) IsLocked(ctx context.Context, key string) (bool, error) {
+	policy, err := e.source.PasswordPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy.MaxInvalid <= 0 {
+		return false, nil
+	}
+	attempts, err := e.lockout.Attempts(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return attempts >= policy.MaxInvalid, nil
+}
+
+// ResetFailures clears the failed attempts of key, e.g. after a successful login.
+func (e *Engine) ResetFailures(ctx context.Context, key string) error {
+	return e.lockout.Reset(ctx, key)
+}
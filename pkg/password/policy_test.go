@@ -0,0 +1,101 @@
+package password
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		candidate string
+		rules     []Rule
+	}{
+		{name: "no rules", candidate: "a"},
+		{name: "too short", policy: Policy{MinLength: 8}, candidate: "Abc1!", rules: []Rule{RuleLength}},
+		{name: "length counts characters", policy: Policy{MinLength: 4}, candidate: "ñáéí"},
+		{name: "missing number", policy: Policy{RequireNumeric: true}, candidate: "Password!", rules: []Rule{RuleNumeric}},
+		{name: "missing letter", policy: Policy{RequireAlphabet: true}, candidate: "12345678!", rules: []Rule{RuleAlphabet}},
+		{name: "missing uppercase", policy: Policy{RequireUppercase: true}, candidate: "password1!", rules: []Rule{RuleUppercase}},
+		{name: "missing symbol", policy: Policy{RequireSymbol: true}, candidate: "Password1", rules: []Rule{RuleSymbol}},
+		{
+			name: "every rule met",
+			policy: Policy{
+				MinLength: 8, RequireNumeric: true, RequireAlphabet: true, RequireUppercase: true, RequireSymbol: true,
+			},
+			candidate: "Password1!",
+		},
+		{
+			name: "every rule broken",
+			policy: Policy{
+				MinLength: 8, RequireNumeric: true, RequireAlphabet: true, RequireUppercase: true, RequireSymbol: true,
+			},
+			candidate: " ",
+			rules:     []Rule{RuleLength, RuleNumeric, RuleAlphabet, RuleUppercase, RuleSymbol},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []Rule
+			for _, violation := range tt.policy.Validate(tt.candidate) {
+				assert.NotEmpty(t, violation.Message)
+				rules = append(rules, violation.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestPolicyIsReused(t *testing.T) {
+	history := []string{HashPassword("third"), HashPassword("second"), HashPassword("first")}
+
+	tests := []struct {
+		name      string
+		history   int
+		candidate string
+		reused    bool
+	}{
+		{name: "history disabled", history: 0, candidate: "third"},
+		{name: "last password", history: 1, candidate: "third", reused: true},
+		{name: "older than the history", history: 2, candidate: "first"},
+		{name: "within the history", history: 3, candidate: "first", reused: true},
+		{name: "history longer than stored", history: 5, candidate: "second", reused: true},
+		{name: "new password", history: 3, candidate: "fourth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.reused, Policy{History: tt.history}.IsReused(tt.candidate, history))
+		})
+	}
+}
+
+func TestPolicyExpiresAt(t *testing.T) {
+	from := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   *time.Time
+	}{
+		{name: "never expires", policy: Policy{ExpiredPeriod: PeriodDays}},
+		{name: "days", policy: Policy{ExpirationCount: 30, ExpiredPeriod: PeriodDays}, want: ptr(from.AddDate(0, 0, 30))},
+		{name: "months", policy: Policy{ExpirationCount: 3, ExpiredPeriod: PeriodMonths}, want: ptr(from.AddDate(0, 3, 0))},
+		{name: "years", policy: Policy{ExpirationCount: 1, ExpiredPeriod: PeriodYears}, want: ptr(from.AddDate(1, 0, 0))},
+		{name: "unknown period counts days", policy: Policy{ExpirationCount: 2}, want: ptr(from.AddDate(0, 0, 2))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.ExpiresAt(from))
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Subject is what a policy is evaluated against, read from the roles and
+// permission claims of the access token.
+type Subject struct {
+	Roles       []string
+	Permissions []string
+}
+
+// SubjectFromClaims reads the roles and permission claims of a decoded token.
+func SubjectFromClaims(claims map[string]interface{}) Subject {
+	return Subject{
+		Roles:       stringList(claims["roles"]),
+		Permissions: stringList(claims["permission"]),
+	}
+}
+
+func stringList(value interface{}) []string {
+	switch value := value.(type) {
+	case []string:
+		return value
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// Policy guards a route. A subject passes when it has any of AnyRole (if
+// set) and every one of AllPermissions.
+type Policy struct {
+	AnyRole        []string `json:"any_role,omitempty"`
+	AllPermissions []string `json:"all_permissions,omitempty"`
+}
+
+// Roles requires any of roles.
+func Roles(roles ...string) Policy {
+	return Policy{AnyRole: roles}
+}
+
+// Permissions requires every one of permissions.
+func Permissions(permissions ...string) Policy {
+	return Policy{AllPermissions: permissions}
+}
+
+// Evaluate returns nil when subject passes p, otherwise an error naming the
+// missing role or permission.
+func (p Policy) Evaluate(subject Subject) error {
+	if len(p.AnyRole) > 0 && !containsAny(subject.Roles, p.AnyRole) {
+		return fmt.Errorf("requires role %s", strings.Join(p.AnyRole, " or "))
+	}
+	for _, permission := range p.AllPermissions {
+		if !Contains(subject.Permissions, permission) {
+			return fmt.Errorf("requires permission %s", permission)
+		}
+	}
+	return nil
+}
+
+func (p Policy) String() string {
+	var parts []string
+	if len(p.AnyRole) > 0 {
+		parts = append(parts, "role:"+strings.Join(p.AnyRole, "|"))
+	}
+	if len(p.AllPermissions) > 0 {
+		parts = append(parts, "permission:"+strings.Join(p.AllPermissions, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// EvaluateAll evaluates every policy, the first failure is returned.
+func EvaluateAll(subject Subject, policies []Policy) error {
+	for _, p := range policies {
+		if err := p.Evaluate(subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Contains reports whether list holds value.
+func Contains(list []string, value string) bool {
+	return containsAny(list, []string{value})
+}
@@ -0,0 +1,42 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"boiler-plate/pkg/memstorage"
+)
+
+// MemoryStore keeps revocations in process memory. Every pod has its own
+// list, use SQLStore when the service runs more than one replica.
+type MemoryStore struct {
+	tokens   *memstorage.MemStorage[string, struct{}]
+	subjects *memstorage.MemStorage[int64, time.Time]
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens:   memstorage.NewMemStorage[string, struct{}](),
+		subjects: memstorage.NewMemStorage[int64, time.Time](),
+	}
+}
+
+func (s *MemoryStore) RevokeToken(_ context.Context, tokenID string, _ int64, expiresAt time.Time) error {
+	if ttl := time.Until(expiresAt); ttl > 0 {
+		s.tokens.Set(tokenID, struct{}{}, ttl)
+	}
+	return nil
+}
+
+func (s *MemoryStore) RevokeSubject(_ context.Context, subjectID int64, ttl time.Duration) error {
+	s.subjects.Set(subjectID, time.Now(), ttl)
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(_ context.Context, tokenID string, subjectID int64, issuedAt time.Time) (bool, error) {
+	if _, found := s.tokens.Get(tokenID); found && tokenID != "" {
+		return true, nil
+	}
+	cutoff, found := s.subjects.Get(subjectID)
+	return found && revokedBefore(issuedAt, cutoff), nil
+}
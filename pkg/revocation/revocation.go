@@ -0,0 +1,25 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps revoked access tokens until they would have expired anyway.
+type Store interface {
+	// RevokeToken revokes the token with the given jti until expiresAt.
+	RevokeToken(ctx context.Context, tokenID string, subjectID int64, expiresAt time.Time) error
+	// RevokeSubject revokes every token of subjectID issued up to now. The
+	// entry is kept for ttl, the lifetime of the longest token.
+	RevokeSubject(ctx context.Context, subjectID int64, ttl time.Duration) error
+	// IsRevoked reports whether the token with jti tokenID, issued to
+	// subjectID at issuedAt, was revoked.
+	IsRevoked(ctx context.Context, tokenID string, subjectID int64, issuedAt time.Time) (bool, error)
+}
+
+// revokedBefore reports whether a token issued at issuedAt falls under a
+// subject revocation made at cutoff. JWT timestamps have second precision,
+// so a token issued in the same second as the revocation is revoked too.
+func revokedBefore(issuedAt, cutoff time.Time) bool {
+	return !issuedAt.After(cutoff.Truncate(time.Second))
+}
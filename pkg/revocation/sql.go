@@ -0,0 +1,124 @@
+package revocation
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"boiler-plate/pkg/errs"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RevokedToken is a single revoked access token.
+type RevokedToken struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TokenID   string    `gorm:"size:64;not null;index" json:"token_id"`
+	SubjectID int64     `gorm:"index" json:"subject_id"`
+	ExpiredAt time.Time `gorm:"index" json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (model *RevokedToken) TableName() string {
+	return os.Getenv("DB_PREFIX") + "revoked_tokens"
+}
+
+// RevokedSubject revokes every token of a subject issued before RevokedBefore.
+type RevokedSubject struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubjectID     int64     `gorm:"not null;index" json:"subject_id"`
+	RevokedBefore time.Time `json:"revoked_before"`
+	ExpiredAt     time.Time `gorm:"index" json:"expired_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (model *RevokedSubject) TableName() string {
+	return os.Getenv("DB_PREFIX") + "revoked_subjects"
+}
+
+// SQLStore shares revocations between every pod through the database.
+type SQLStore struct {
+	db            *gorm.DB
+	purgeInterval time.Duration
+}
+
+// NewSQLStore creates a SQLStore, Run deletes expired rows every
+// purgeInterval.
+func NewSQLStore(db *gorm.DB, purgeInterval time.Duration) *SQLStore {
+	return &SQLStore{db: db, purgeInterval: purgeInterval}
+}
+
+func (s *SQLStore) RevokeToken(ctx context.Context, tokenID string, subjectID int64, expiresAt time.Time) error {
+	if err := s.db.WithContext(ctx).Create(&RevokedToken{
+		TokenID:   tokenID,
+		SubjectID: subjectID,
+		ExpiredAt: expiresAt,
+	}).Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (s *SQLStore) RevokeSubject(ctx context.Context, subjectID int64, ttl time.Duration) error {
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Create(&RevokedSubject{
+		SubjectID:     subjectID,
+		RevokedBefore: now,
+		ExpiredAt:     now.Add(ttl),
+	}).Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (s *SQLStore) IsRevoked(ctx context.Context, tokenID string, subjectID int64, issuedAt time.Time) (bool, error) {
+	now := time.Now()
+	var count int64
+	if tokenID != "" {
+		if err := s.db.WithContext(ctx).
+			Model(&RevokedToken{}).
+			Where("token_id = ? AND expired_at > ?", tokenID, now).
+			Count(&count).
+			Error; err != nil {
+			return false, errs.Wrap(err)
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	if err := s.db.WithContext(ctx).
+		Model(&RevokedSubject{}).
+		Where("subject_id = ? AND revoked_before >= ? AND expired_at > ?", subjectID, issuedAt, now).
+		Count(&count).
+		Error; err != nil {
+		return false, errs.Wrap(err)
+	}
+	return count > 0, nil
+}
+
+// Purge deletes revocations whose tokens have expired.
+func (s *SQLStore) Purge(ctx context.Context) error {
+	now := time.Now()
+	for _, model := range []interface{}{&RevokedToken{}, &RevokedSubject{}} {
+		if err := s.db.WithContext(ctx).Where("expired_at <= ?", now).Delete(model).Error; err != nil {
+			return errs.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Run purges expired revocations until ctx is cancelled.
+func (s *SQLStore) Run(ctx context.Context) error {
+	for {
+		if err := s.Purge(ctx); err != nil {
+			logrus.Errorf("revocation purge error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.purgeInterval):
+		}
+	}
+}
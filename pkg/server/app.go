@@ -1,43 +1,28 @@
 package server
 
-import "boiler-plate/app/appconf"
+import (
+	"boiler-plate/app/appconf"
+	"boiler-plate/pkg/exception"
+	"boiler-plate/pkg/httputils"
+)
 
 type App interface {
 	Run(config *appconf.Config) error
 }
 
-type LogMessage struct {
-	CompanyID  int64
-	EmployeeID int64
-	Email      string
-	Log        string
-}
-
-const (
-	DefaultResponseType = iota // For API
-	StreamResponseType         // For Excel
-)
-
+// ResponseInterface is what a handler returns, the run action of the base
+// handler turns it into the response envelope.
 type ResponseInterface struct {
-	Status int         `json:"status"`
-	Data   interface{} `json:"data"`
-}
-
-type Response struct {
-	Status       int         `json:"status"`
-	Message      string      `json:"message"`
-	Data         interface{} `json:"data"`
-	RequestID    string      `json:"request_id"`
-	StackTrace   string      `json:"-"`
-	Log          *LogMessage `json:"-"`
-	ResponseType int         `json:"-"`
-}
-
-type ResponseSignature struct {
-	Signature string `json:"signature"`
-}
-
-func (r Response) GetStatus() int {
-	return r.Status
-
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+	// Meta is the pagination of a list response.
+	Meta *httputils.Meta `json:"-"`
+	// Raw writes Data as the body without the envelope, for bodies defined
+	// by a protocol like JWKS or the OAuth2 token response.
+	Raw bool `json:"-"`
+	// Signed asks the run action to sign the serialized body.
+	Signed bool `json:"-"`
+	// Err is rendered by the run action instead of Data.
+	Err *exception.Exception `json:"-"`
 }
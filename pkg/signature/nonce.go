@@ -0,0 +1,117 @@
+package signature
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	baseModel "boiler-plate/pkg/db"
+	"boiler-plate/pkg/errs"
+	"boiler-plate/pkg/memstorage"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// NonceStore remembers the signed requests already received so a replay is
+// rejected, whichever pod it reaches.
+type NonceStore interface {
+	// Claim records key until ttl from now. It reports false when key was
+	// already claimed and has not expired.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore keeps the nonces in process memory. Every pod has its own
+// list, a request replayed to another pod is accepted; use SQLNonceStore when
+// the service runs more than one replica.
+type MemoryNonceStore struct {
+	mu   sync.Mutex // Makes the lookup and insert atomic.
+	seen *memstorage.MemStorage[string, struct{}]
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{seen: memstorage.NewMemStorage[string, struct{}]()}
+}
+
+func (s *MemoryNonceStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.seen.Get(key); found {
+		return false, nil
+	}
+	s.seen.Set(key, struct{}{}, ttl)
+	return true, nil
+}
+
+// Nonce is the digest of a signed request, remembered until ExpiredAt.
+type Nonce struct {
+	Digest    string    `gorm:"primaryKey;size:64" json:"digest"`
+	ExpiredAt time.Time `gorm:"index;not null" json:"expired_at"`
+}
+
+func (model *Nonce) TableName() string {
+	return os.Getenv("DB_PREFIX") + "signature_nonces"
+}
+
+// SQLNonceStore shares the nonces between every pod through the database,
+// the primary key settles concurrent claims.
+type SQLNonceStore struct {
+	db            *gorm.DB
+	purgeInterval time.Duration
+}
+
+// NewSQLNonceStore creates a SQLNonceStore, Run deletes expired rows every
+// purgeInterval.
+func NewSQLNonceStore(db *gorm.DB, purgeInterval time.Duration) *SQLNonceStore {
+	return &SQLNonceStore{db: db, purgeInterval: purgeInterval}
+}
+
+func (s *SQLNonceStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	// keys are hashed to fit the column whatever the client key length
+	digest := sha256.Sum256([]byte(key))
+	hashed := hex.EncodeToString(digest[:])
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Create(&Nonce{Digest: hashed, ExpiredAt: now.Add(ttl)}).Error
+	if err == nil {
+		return true, nil
+	}
+	if !baseModel.IsDuplicateKey(err) {
+		return false, errs.Wrap(err)
+	}
+
+	// an expired row not purged yet is taken over
+	result := s.db.WithContext(ctx).
+		Model(&Nonce{}).
+		Where("digest = ? AND expired_at <= ?", hashed, now).
+		Update("expired_at", now.Add(ttl))
+	if result.Error != nil {
+		return false, errs.Wrap(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Purge deletes expired nonces.
+func (s *SQLNonceStore) Purge(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).Where("expired_at <= ?", time.Now()).Delete(&Nonce{}).Error; err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// Run purges expired nonces until ctx is cancelled.
+func (s *SQLNonceStore) Run(ctx context.Context) error {
+	for {
+		if err := s.Purge(ctx); err != nil {
+			logrus.Errorf("signature nonce purge error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.purgeInterval):
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Headers of a signed response. X-TIMESTAMP and X-SIGNATURE are shared with
+// signed requests.
+const (
+	HeaderRequestID = "X-API-Request-ID"
+	HeaderKeyID     = "X-SIGNATURE-KEY-ID"
+)
+
+var (
+	ErrMissingResponseSignature = errors.New("signature: response is not signed")
+	ErrRequestIDMismatch        = errors.New("signature: response was signed for another request")
+)
+
+// ResponseStringToSign builds hex(sha256(body)):TIMESTAMP:REQUEST_ID. The
+// body is hashed exactly as sent, it is not minified.
+func ResponseStringToSign(body []byte, timestamp, requestID string) string {
+	digest := sha256.Sum256(body)
+	return strings.Join([]string{hex.EncodeToString(digest[:]), timestamp, requestID}, ":")
+}
+
+// ResponseSigner signs response bodies with the service private key.
+type ResponseSigner struct {
+	keyID  string
+	signer crypto.Signer
+	now    func() time.Time
+}
+
+func NewResponseSigner(keyID string, signer crypto.Signer) *ResponseSigner {
+	return &ResponseSigner{keyID: keyID, signer: signer, now: time.Now}
+}
+
+// LoadResponseSigner reads a PEM encoded RSA or ECDSA private key.
+func LoadResponseSigner(keyID, path string) (*ResponseSigner, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("signature: %s is not a PEM file", path)
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+	if err := verifyDigest(signer.Public(), make([]byte, sha256.Size), nil); errors.Is(err, ErrUnsupportedKeyType) {
+		return nil, err
+	}
+	return NewResponseSigner(keyID, signer), nil
+}
+
+// Sign returns the headers to send with body.
+func (s *ResponseSigner) Sign(body []byte, requestID string) (http.Header, error) {
+	timestamp := s.now().Format(TimestampLayout)
+	signature, err := Sign(s.signer, ResponseStringToSign(body, timestamp, requestID))
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderRequestID, requestID)
+	header.Set(HeaderSignature, signature)
+	if s.keyID != "" {
+		header.Set(HeaderKeyID, s.keyID)
+	}
+	return header, nil
+}
+
+// PublicKeyPEM returns the PKIX public key partners verify responses with.
+func (s *ResponseSigner) PublicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(s.signer.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func (s *ResponseSigner) KeyID() string {
+	return s.keyID
+}
+
+// VerifyResponse checks the signature of a response body, the counterpart
+// of ResponseSigner.Sign.
+func VerifyResponse(publicKey crypto.PublicKey, body []byte, timestamp, requestID, signature string) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingResponseSignature
+	}
+	decoded, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(ResponseStringToSign(body, timestamp, requestID)))
+	return verifyDigest(publicKey, digest[:], decoded)
+}
+
+// VerifyHTTPResponse reads and verifies resp as received by a partner. The
+// response must answer the request sent with requestID as X-API-Request-ID
+// and be signed at most maxAge ago, so a response captured earlier cannot be
+// replayed. The body is put back so it can still be decoded afterwards.
+func VerifyHTTPResponse(publicKey crypto.PublicKey, resp *http.Response, requestID string, maxAge time.Duration) error {
+	return verifyHTTPResponse(publicKey, resp, requestID, maxAge, time.Now())
+}
+
+func verifyHTTPResponse(publicKey crypto.PublicKey, resp *http.Response, requestID string, maxAge time.Duration, now time.Time) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	timestamp := resp.Header.Get(HeaderTimestamp)
+	signature := resp.Header.Get(HeaderSignature)
+	if signature == "" || timestamp == "" {
+		return ErrMissingResponseSignature
+	}
+	if got := resp.Header.Get(HeaderRequestID); got != requestID {
+		return ErrRequestIDMismatch
+	}
+	signedAt, err := time.Parse(TimestampLayout, timestamp)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if age := now.Sub(signedAt); age > maxAge || age < -maxAge {
+		return ErrTimestampSkew
+	}
+	return VerifyResponse(publicKey, body, timestamp, requestID, signature)
+}
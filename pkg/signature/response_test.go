@@ -0,0 +1,186 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	requestID    = "6f1c2a9e-3b7d-4c1e-9a55-2f0d8e7b1c44"
+	responseBody = `{"status":200,"message":"success","data":{"app_name":"boiler-plate"}}`
+)
+
+// signedResponse returns the response a partner receives from a route signed
+// by signer at now.
+func signedResponse(t *testing.T, signer crypto.Signer) *http.Response {
+	s := NewResponseSigner("2026-10", signer)
+	s.now = func() time.Time { return now }
+	header, err := s.Sign([]byte(responseBody), requestID)
+	require.NoError(t, err)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader([]byte(responseBody))),
+	}
+}
+
+func TestResponseSignRoundTrip(t *testing.T) {
+	for name, signer := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecdsaKey} {
+		t.Run(name, func(t *testing.T) {
+			resp := signedResponse(t, signer)
+			assert.Equal(t, requestID, resp.Header.Get(HeaderRequestID))
+			assert.Equal(t, "2026-10", resp.Header.Get(HeaderKeyID))
+			assert.Equal(t, now.Format(TimestampLayout), resp.Header.Get(HeaderTimestamp))
+
+			require.NoError(t, verifyHTTPResponse(signer.Public(), resp, requestID, time.Minute, now.Add(30*time.Second)))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, responseBody, string(body), "the body can be read after verifying")
+		})
+	}
+}
+
+func TestVerifyHTTPResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		tamper    func(resp *http.Response)
+		publicKey crypto.PublicKey
+		requestID string
+		at        time.Time
+		err       error
+	}{
+		{
+			name:   "tampered body",
+			tamper: func(resp *http.Response) { resp.Body = io.NopCloser(bytes.NewReader([]byte(`{"status":500}`))) },
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "reformatted body",
+			tamper: func(resp *http.Response) { resp.Body = io.NopCloser(bytes.NewReader([]byte(responseBody + "\n"))) },
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:      "response to another request",
+			requestID: "another-request",
+			err:       ErrRequestIDMismatch,
+		},
+		{
+			name:   "request id replaced",
+			tamper: func(resp *http.Response) { resp.Header.Set(HeaderRequestID, "another-request") },
+			err:    ErrRequestIDMismatch,
+		},
+		{
+			name: "timestamp replaced",
+			tamper: func(resp *http.Response) {
+				resp.Header.Set(HeaderTimestamp, now.Add(time.Second).Format(TimestampLayout))
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "signed too long ago",
+			at:   now.Add(time.Minute + time.Second),
+			err:  ErrTimestampSkew,
+		},
+		{
+			name: "signed in the future",
+			at:   now.Add(-time.Minute - time.Second),
+			err:  ErrTimestampSkew,
+		},
+		{
+			name:   "invalid timestamp",
+			tamper: func(resp *http.Response) { resp.Header.Set(HeaderTimestamp, "yesterday") },
+			err:    ErrInvalidTimestamp,
+		},
+		{
+			name:   "unsigned",
+			tamper: func(resp *http.Response) { resp.Header.Del(HeaderSignature) },
+			err:    ErrMissingResponseSignature,
+		},
+		{
+			name:      "another key",
+			publicKey: otherKey.Public(),
+			err:       ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := signedResponse(t, ecdsaKey)
+			if tt.tamper != nil {
+				tt.tamper(resp)
+			}
+			publicKey := tt.publicKey
+			if publicKey == nil {
+				publicKey = ecdsaKey.Public()
+			}
+			id := tt.requestID
+			if id == "" {
+				id = requestID
+			}
+			at := tt.at
+			if at.IsZero() {
+				at = now
+			}
+			assert.ErrorIs(t, verifyHTTPResponse(publicKey, resp, id, time.Minute, at), tt.err)
+		})
+	}
+}
+
+func TestLoadResponseSigner(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+		return path
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecdsaKey)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecdsaKey)
+	require.NoError(t, err)
+
+	for name, path := range map[string]string{
+		"pkcs1": write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		"ec":    write("ec.pem", "EC PRIVATE KEY", ecDER),
+		"pkcs8": write("pkcs8.pem", "PRIVATE KEY", pkcs8),
+	} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := LoadResponseSigner("2026-10", path)
+			require.NoError(t, err)
+			assert.Equal(t, "2026-10", signer.KeyID())
+
+			// partners verify with the published public key
+			published, err := signer.PublicKeyPEM()
+			require.NoError(t, err)
+			publicKey, err := ParsePublicKey(published)
+			require.NoError(t, err)
+
+			header, err := signer.Sign([]byte(responseBody), requestID)
+			require.NoError(t, err)
+			resp := &http.Response{Header: header, Body: io.NopCloser(bytes.NewReader([]byte(responseBody)))}
+			assert.NoError(t, VerifyHTTPResponse(publicKey, resp, requestID, time.Minute))
+		})
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	_, err = LoadResponseSigner("", write("ed25519.pem", "PRIVATE KEY", edDER))
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+
+	_, err = LoadResponseSigner("", filepath.Join(dir, "missing.pem"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
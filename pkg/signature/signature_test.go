@@ -0,0 +1,324 @@
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type registry map[string]*Key
+
+func (r registry) SignatureKey(_ context.Context, clientID string) (*Key, error) {
+	return r[clientID], nil
+}
+
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now         = time.Date(2026, 10, 18, 10, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+)
+
+func newTestVerifier() *Verifier {
+	v := NewVerifier(registry{
+		"rsa-client":   {ClientID: "rsa-client", PublicKey: rsaKey.Public(), Scopes: []string{"settings:read"}},
+		"ecdsa-client": {ClientID: "ecdsa-client", PublicKey: ecdsaKey.Public()},
+	}, 5*time.Minute, NewMemoryNonceStore())
+	v.now = func() time.Time { return now }
+	return v
+}
+
+// signed returns a request to POST /api/v2/b2b/orders signed by signer.
+func signed(t *testing.T, signer crypto.Signer, clientKey string, timestamp time.Time) *Request {
+	req := &Request{
+		Method:    "POST",
+		Path:      "/api/v2/b2b/orders?page=1",
+		Body:      []byte(`{"amount": 1000, "currency": "IDR"}`),
+		ClientKey: clientKey,
+		Timestamp: timestamp.Format(TimestampLayout),
+	}
+	resign(t, signer, req)
+	return req
+}
+
+func resign(t *testing.T, signer crypto.Signer, req *Request) {
+	signature, err := Sign(signer, StringToSign(req.Method, req.Path, req.Body, req.Timestamp, req.Nonce))
+	require.NoError(t, err)
+	req.Signature = signature
+}
+
+func TestStringToSign(t *testing.T) {
+	digest := sha256.Sum256([]byte(`{"amount":1000,"items":[1,2]}`))
+	body := []byte("{\n  \"amount\": 1000,\n  \"items\": [1, 2]\n}")
+
+	assert.Equal(t,
+		"POST:/api/v2/b2b/orders?page=1:"+hex.EncodeToString(digest[:])+":2026-10-18T10:00:00+07:00",
+		StringToSign("post", "/api/v2/b2b/orders?page=1", body, "2026-10-18T10:00:00+07:00", ""),
+		"the method is upper cased and JSON bodies are minified",
+	)
+	assert.Equal(t,
+		"POST:/api/v2/b2b/orders?page=1:"+hex.EncodeToString(digest[:])+":2026-10-18T10:00:00+07:00:abc",
+		StringToSign("POST", "/api/v2/b2b/orders?page=1", body, "2026-10-18T10:00:00+07:00", "abc"),
+	)
+
+	empty := sha256.Sum256(nil)
+	assert.Equal(t,
+		"GET:/api/v2/b2b/me:"+hex.EncodeToString(empty[:])+":2026-10-18T10:00:00Z",
+		StringToSign("GET", "/api/v2/b2b/me", nil, "2026-10-18T10:00:00Z", ""),
+	)
+
+	text := sha256.Sum256([]byte("a  b"))
+	assert.Contains(t, StringToSign("POST", "/", []byte("a  b"), "t", ""), hex.EncodeToString(text[:]),
+		"other bodies are hashed as sent")
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(t *testing.T) *Request
+		client string
+		err    error
+	}{
+		{
+			name:   "rsa",
+			build:  func(t *testing.T) *Request { return signed(t, rsaKey, "rsa-client", now) },
+			client: "rsa-client",
+		},
+		{
+			name:   "ecdsa",
+			build:  func(t *testing.T) *Request { return signed(t, ecdsaKey, "ecdsa-client", now) },
+			client: "ecdsa-client",
+		},
+		{
+			name: "nonce",
+			build: func(t *testing.T) *Request {
+				req := signed(t, ecdsaKey, "ecdsa-client", now)
+				req.Nonce = "8b0f6c1e"
+				resign(t, ecdsaKey, req)
+				return req
+			},
+			client: "ecdsa-client",
+		},
+		{
+			name: "reformatted json body",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Body = []byte("{\n\t\"amount\": 1000,\n\t\"currency\": \"IDR\"\n}")
+				return req
+			},
+			client: "rsa-client",
+		},
+		{
+			name: "timestamp of 25 characters",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				require.Len(t, req.Timestamp, MaxTimestampLength)
+				return req
+			},
+			client: "rsa-client",
+		},
+		{
+			name:   "timestamp at the skew bound",
+			build:  func(t *testing.T) *Request { return signed(t, rsaKey, "rsa-client", now.Add(-5*time.Minute)) },
+			client: "rsa-client",
+		},
+		{
+			name:   "timestamp in the future at the skew bound",
+			build:  func(t *testing.T) *Request { return signed(t, rsaKey, "rsa-client", now.Add(5*time.Minute)) },
+			client: "rsa-client",
+		},
+		{
+			name: "missing client key",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.ClientKey = ""
+				return req
+			},
+			err: ErrMissingClientKey,
+		},
+		{
+			name: "missing timestamp",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Timestamp = ""
+				return req
+			},
+			err: ErrMissingTimestamp,
+		},
+		{
+			name: "timestamp longer than 25 characters",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Timestamp = now.Format(time.RFC3339Nano)[:19] + ".5+07:00"
+				resign(t, rsaKey, req)
+				return req
+			},
+			err: ErrTimestampLength,
+		},
+		{
+			name: "timestamp not in ISO 8601",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Timestamp = now.Format("2006-01-02 15:04:05")
+				resign(t, rsaKey, req)
+				return req
+			},
+			err: ErrInvalidTimestamp,
+		},
+		{
+			name: "timestamp too old",
+			build: func(t *testing.T) *Request {
+				return signed(t, rsaKey, "rsa-client", now.Add(-5*time.Minute-time.Second))
+			},
+			err: ErrTimestampSkew,
+		},
+		{
+			name: "timestamp too far in the future",
+			build: func(t *testing.T) *Request {
+				return signed(t, rsaKey, "rsa-client", now.Add(5*time.Minute+time.Second))
+			},
+			err: ErrTimestampSkew,
+		},
+		{
+			name: "missing signature",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Signature = ""
+				return req
+			},
+			err: ErrMissingSignature,
+		},
+		{
+			name: "signature not base64",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Signature = "not base64!"
+				return req
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name:  "unknown client",
+			build: func(t *testing.T) *Request { return signed(t, rsaKey, "unknown", now) },
+			err:   ErrUnknownClient,
+		},
+		{
+			name:  "signed with another key",
+			build: func(t *testing.T) *Request { return signed(t, otherKey, "ecdsa-client", now) },
+			err:   ErrInvalidSignature,
+		},
+		{
+			name: "tampered body",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Body = []byte(`{"amount": 9000, "currency": "IDR"}`)
+				return req
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "tampered path",
+			build: func(t *testing.T) *Request {
+				req := signed(t, ecdsaKey, "ecdsa-client", now)
+				req.Path = "/api/v2/b2b/orders?page=2"
+				return req
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "tampered method",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Method = "PUT"
+				return req
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "tampered nonce",
+			build: func(t *testing.T) *Request {
+				req := signed(t, rsaKey, "rsa-client", now)
+				req.Nonce = "added-after-signing"
+				return req
+			},
+			err: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := newTestVerifier().Verify(context.Background(), tt.build(t))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, key)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.client, key.ClientID)
+		})
+	}
+}
+
+func TestVerifyRejectsReplays(t *testing.T) {
+	ctx := context.Background()
+	v := newTestVerifier()
+
+	req := signed(t, ecdsaKey, "ecdsa-client", now)
+	_, err := v.Verify(ctx, req)
+	require.NoError(t, err)
+
+	// ECDSA signatures are randomized, signing the same request again is
+	// still a replay
+	again := *req
+	resign(t, ecdsaKey, &again)
+	require.NotEqual(t, req.Signature, again.Signature)
+	_, err = v.Verify(ctx, &again)
+	assert.ErrorIs(t, err, ErrReplayed)
+
+	// a new nonce makes an identical request within the same second distinct
+	again.Nonce = "second-request"
+	resign(t, ecdsaKey, &again)
+	_, err = v.Verify(ctx, &again)
+	assert.NoError(t, err)
+
+	// the same signed string from another client is not a replay
+	_, err = v.Verify(ctx, signed(t, rsaKey, "rsa-client", now))
+	assert.NoError(t, err)
+}
+
+func TestVerifyRejectsUnsupportedKeys(t *testing.T) {
+	v := newTestVerifier()
+	v.registry = registry{"client": {ClientID: "client", PublicKey: []byte("not a key")}}
+	_, err := v.Verify(context.Background(), signed(t, rsaKey, "client", now))
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNonceStore()
+
+	claimed, err := store.Claim(ctx, "client:digest", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = store.Claim(ctx, "client:digest", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	claimed, err = store.Claim(ctx, "short", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	time.Sleep(5 * time.Millisecond)
+	claimed, err = store.Claim(ctx, "short", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, claimed, "an expired nonce can be claimed again")
+}
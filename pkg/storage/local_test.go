@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(filepath.Join(t.TempDir(), "files"), "")
+	require.NoError(t, err)
+
+	content := []byte("logo content")
+	require.NoError(t, store.Put(ctx, "settings/logo.png", bytes.NewReader(content), int64(len(content)), "image/png"))
+
+	reader, object, err := store.Get(ctx, "settings/logo.png")
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	require.NoError(t, reader.Close())
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.Equal(t, "settings/logo.png", object.Key)
+	assert.Equal(t, int64(len(content)), object.Size)
+	assert.Equal(t, "image/png", object.ContentType)
+
+	stat, err := store.Stat(ctx, "settings/logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, object.Size, stat.Size)
+
+	require.NoError(t, store.Delete(ctx, "settings/logo.png"))
+	_, err = store.Stat(ctx, "settings/logo.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = store.Get(ctx, "settings/logo.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "settings/logo.png"), "deleting a missing key is not an error")
+}
+
+func TestLocalPutReplacesContent(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "a.txt", bytes.NewReader([]byte("first version")), 13, "text/plain"))
+	require.NoError(t, store.Put(ctx, "a.txt", bytes.NewReader([]byte("second")), 6, "text/plain"))
+
+	reader, _, err := store.Get(ctx, "a.txt")
+	require.NoError(t, err)
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(got))
+}
+
+func TestLocalList(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocal(root, "")
+	require.NoError(t, err)
+
+	for _, key := range []string{"images/a.png", "images/b.png", "docs/c.pdf"} {
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader([]byte(key)), int64(len(key)), ""))
+	}
+	// a crashed upload leaves its temporary file behind, it is not listed
+	require.NoError(t, os.WriteFile(filepath.Join(root, "images", ".upload-123"), []byte("partial"), 0644))
+
+	objects, err := store.List(ctx, "images/")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	assert.ElementsMatch(t, []string{"images/a.png", "images/b.png"}, keys)
+
+	all, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	store, err := NewLocal(filepath.Join(parent, "root"), "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "../escape.txt", bytes.NewReader([]byte("x")), 1, ""))
+	_, err = os.Stat(filepath.Join(parent, "escape.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "the key is cleaned into the root")
+	_, err = store.Stat(ctx, "escape.txt")
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/", `..\escape.txt`} {
+		assert.Error(t, store.Put(ctx, key, bytes.NewReader(nil), 0, ""), "key %q", key)
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	_, err = store.SignedURL(ctx, "logo.png", time.Minute)
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	store, err = NewLocal(t.TempDir(), "https://cdn.example.com/files")
+	require.NoError(t, err)
+	signed, err := store.SignedURL(ctx, "settings/logo.png", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/files/settings/logo.png", signed)
+}
+
+func TestNewSelectsDriver(t *testing.T) {
+	store, err := New(&Config{Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &Local{}, store)
+
+	_, err = New(&Config{Driver: "ftp"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a MinIO style stand-in implementing the subset of the S3 API
+// used by the S3 backend. Signatures are not checked.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{buckets: make(map[string]map[string]fakeObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, exists := f.buckets[bucket]
+	if key == "" {
+		switch {
+		case r.Method == http.MethodHead:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut:
+			f.buckets[bucket] = make(map[string]fakeObject)
+		case r.Method == http.MethodGet && exists:
+			f.list(w, bucket, objects, r.URL.Query().Get("prefix"))
+		default:
+			writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		}
+		return
+	}
+	if !exists {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+		w.Header().Set("ETag", etag(data))
+	case http.MethodHead, http.MethodGet:
+		object, ok := objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("ETag", etag(object.data))
+		w.Header().Set("Last-Modified", object.modTime.Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(object.data)
+		}
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket string, objects map[string]fakeObject, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix}
+	for key, object := range objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{
+				Key:          key,
+				LastModified: object.modTime.Format(time.RFC3339),
+				ETag:         etag(object.data),
+				Size:         len(object.data),
+			})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// readS3Body decodes the aws-chunked body minio-go sends over plain HTTP.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func newTestS3(t *testing.T) (*fakeS3, *httptest.Server, *S3) {
+	fake, server := newFakeS3(t)
+	endpoint, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	store, err := NewS3(&Config{
+		Driver:    DriverS3,
+		Endpoint:  endpoint.Host,
+		Region:    "us-east-1",
+		Bucket:    "uploads",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+	return fake, server, store
+}
+
+func TestNewS3CreatesBucket(t *testing.T) {
+	fake, _, _ := newTestS3(t)
+	assert.Contains(t, fake.buckets, "uploads")
+}
+
+func TestS3PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	_, _, store := newTestS3(t)
+
+	content := []byte("favicon content")
+	require.NoError(t, store.Put(ctx, "settings/favicon.ico", bytes.NewReader(content), int64(len(content)), "image/x-icon"))
+
+	reader, object, err := store.Get(ctx, "settings/favicon.ico")
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	require.NoError(t, reader.Close())
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.Equal(t, int64(len(content)), object.Size)
+	assert.Equal(t, "image/x-icon", object.ContentType)
+	assert.NotEmpty(t, object.ETag)
+
+	_, ok := reader.(io.Seeker)
+	assert.True(t, ok, "the reader must support seeking for http.ServeContent")
+
+	require.NoError(t, store.Delete(ctx, "settings/favicon.ico"))
+	_, err = store.Stat(ctx, "settings/favicon.ico")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = store.Get(ctx, "settings/favicon.ico")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3List(t *testing.T) {
+	ctx := context.Background()
+	_, _, store := newTestS3(t)
+
+	for _, key := range []string{"images/a.png", "images/b.png", "docs/c.pdf"} {
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader([]byte(key)), int64(len(key)), ""))
+	}
+
+	objects, err := store.List(ctx, "images/")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "images/a.png", objects[0].Key)
+	assert.Equal(t, int64(len("images/a.png")), objects[0].Size)
+	assert.Equal(t, "images/b.png", objects[1].Key)
+}
+
+func TestS3SignedURL(t *testing.T) {
+	ctx := context.Background()
+	_, server, store := newTestS3(t)
+
+	content := []byte("signed content")
+	require.NoError(t, store.Put(ctx, "logo.png", bytes.NewReader(content), int64(len(content)), "image/png"))
+
+	signed, err := store.SignedURL(ctx, "logo.png", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, server.URL+"/uploads/logo.png?"))
+	query, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "60", query.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, query.Query().Get("X-Amz-Signature"))
+
+	resp, err := http.Get(signed)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	// ErrNotFound is returned when the requested key does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrNotSupported is returned when a backend cannot perform an operation.
+	ErrNotSupported = errors.New("storage: operation not supported")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mod_time"`
+	ETag        string    `json:"etag"`
+}
+
+// Storage abstracts where uploaded files live, keys are slash separated paths
+// relative to the storage root or bucket.
+type Storage interface {
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+	// Get returns the content of key. The reader also implements io.Seeker for
+	// every backend in this package. Callers must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes key, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*Object, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a URL that grants read access to key until expiry.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Config selects and configures a Storage backend.
+type Config struct {
+	Driver string
+	// Local driver
+	Path      string
+	PublicURL string
+	// S3 driver
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// New creates the backend selected by config.Driver.
+func New(config *Config) (Storage, error) {
+	switch config.Driver {
+	case DriverLocal, "":
+		return NewLocal(config.Path, config.PublicURL)
+	case DriverS3:
+		return NewS3(config)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", config.Driver)
+	}
+}
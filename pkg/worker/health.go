@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// ConsumerStatus is the health of one registered consumer.
+type ConsumerStatus struct {
+	Instances   int        `json:"instances"`
+	Running     int        `json:"running"`
+	Restarts    int        `json:"restarts"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type Health struct {
+	Status    string                    `json:"status"`
+	Consumers map[string]ConsumerStatus `json:"consumers"`
+}
+
+// Health reports degraded when an instance of any consumer is not running.
+func (w *Worker) Health() Health {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	health := Health{Status: StatusOK, Consumers: make(map[string]ConsumerStatus, len(w.status))}
+	for name, status := range w.status {
+		health.Consumers[name] = *status
+		if status.Running < status.Instances {
+			health.Status = StatusDegraded
+		}
+	}
+	return health
+}
+
+// HealthHandler serves Health as JSON, with status 503 when degraded.
+func (w *Worker) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		health := w.Health()
+		rw.Header().Set("Content-Type", "application/json")
+		if health.Status != StatusOK {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(rw).Encode(health)
+	})
+}
+
+// ServeHealth listens on addr until ctx is cancelled.
+func (w *Worker) ServeHealth(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/health-check", w.HealthHandler())
+	w.mu.Lock()
+	for pattern, handler := range w.handlers {
+		mux.Handle(pattern, handler)
+	}
+	w.mu.Unlock()
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	minRestartBackoff = time.Second
+	maxRestartBackoff = time.Minute
+)
+
+// ErrDrainTimeout is returned by Run when consumers did not stop within the
+// drain timeout after the context was cancelled.
+var ErrDrainTimeout = errors.New("worker: drain timeout exceeded")
+
+// Runner is a long running consumer. Run must return once ctx is cancelled,
+// after finishing the message in flight.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// Factory creates one consumer instance, every instance joins the same
+// consumer group so the topic partitions are shared between them.
+type Factory func() Runner
+
+type registration struct {
+	name        string
+	concurrency int
+	factory     Factory
+}
+
+// Worker runs registered consumers, restarting instances that fail, and
+// keeps their status for health reporting.
+type Worker struct {
+	mu            sync.Mutex
+	registrations []registration
+	status        map[string]*ConsumerStatus
+	drainTimeout  time.Duration
+	handlers      map[string]http.Handler
+}
+
+func New(drainTimeout time.Duration) *Worker {
+	return &Worker{
+		status:       map[string]*ConsumerStatus{},
+		drainTimeout: drainTimeout,
+		handlers:     map[string]http.Handler{},
+	}
+}
+
+// Handle adds a route to the health server, it must be called before
+// ServeHealth.
+func (w *Worker) Handle(pattern string, handler http.Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[pattern] = handler
+}
+
+// Register adds a consumer, usually named after its topic, running
+// concurrency instances.
+func (w *Worker) Register(name string, concurrency int, factory Factory) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.registrations = append(w.registrations, registration{name: name, concurrency: concurrency, factory: factory})
+	w.status[name] = &ConsumerStatus{Instances: concurrency}
+}
+
+// Run starts every registered consumer and blocks until ctx is cancelled and
+// all instances have drained, or the drain timeout passes.
+func (w *Worker) Run(ctx context.Context) error {
+	w.mu.Lock()
+	registrations := append([]registration(nil), w.registrations...)
+	w.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, reg := range registrations {
+		logrus.Infof("starting consumer %s with %d instances", reg.name, reg.concurrency)
+		for i := 0; i < reg.concurrency; i++ {
+			wg.Add(1)
+			go func(reg registration, instance int) {
+				defer wg.Done()
+				w.supervise(ctx, reg, instance)
+			}(reg, i)
+		}
+	}
+
+	<-ctx.Done()
+	logrus.Infoln("draining consumers")
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logrus.Infoln("consumers drained")
+		return nil
+	case <-time.After(w.drainTimeout):
+		return ErrDrainTimeout
+	}
+}
+
+// supervise runs one instance, restarting it with backoff when it fails.
+func (w *Worker) supervise(ctx context.Context, reg registration, instance int) {
+	log := logrus.WithFields(logrus.Fields{"consumer": reg.name, "instance": instance})
+	backoff := minRestartBackoff
+	for {
+		w.update(reg.name, func(s *ConsumerStatus) { s.Running++ })
+		err := reg.factory().Run(ctx)
+		w.update(reg.name, func(s *ConsumerStatus) {
+			s.Running--
+			if err != nil {
+				s.Restarts++
+				s.LastError = err.Error()
+				now := time.Now()
+				s.LastErrorAt = &now
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("consumer returned before shutdown")
+		}
+		log.WithError(err).Errorf("consumer stopped, restarting in %s", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+func (w *Worker) update(name string, fn func(*ConsumerStatus)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(w.status[name])
+}
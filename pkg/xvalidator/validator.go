@@ -90,7 +90,7 @@ func (v *Validator) formatValidationError(err error) map[string]string {
 	errors := make(map[string]string)
 	for _, err := range err.(validator.ValidationErrors) {
 		switch err.Tag() {
-		case "required":
+		case "required", "required_if", "required_unless", "required_with", "required_without":
 			errors[err.Field()] = fmt.Sprintf("%s is required", err.Field())
 		case "email":
 			errors[err.Field()] = fmt.Sprintf("%s is not a valid email", err.Field())
@@ -112,6 +112,10 @@ func (v *Validator) formatValidationError(err error) map[string]string {
 			errors[err.Field()] = fmt.Sprintf("%s must be numeric", err.Field())
 		case "number":
 			errors[err.Field()] = fmt.Sprintf("%s must be a number", err.Field())
+		case "file":
+			errors[err.Field()] = fmt.Sprintf("%s must be an existing file", err.Field())
+		case "hostname_port":
+			errors[err.Field()] = fmt.Sprintf("%s must be host:port", err.Field())
 		case "phone":
 			errors[err.Field()] = fmt.Sprintf("%s invalid phone number", err.Field())
 		default: